@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+// processCache is a Platform that reuses the process list of another Platform
+// until it is older than the refresh interval
+type processCache struct {
+	platform tree.Platform
+	interval time.Duration    // 0 collects processes on every call
+	now      func() time.Time // nil means time.Now
+
+	mu        sync.Mutex
+	processes []tree.Process
+	collected time.Time
+}
+
+// newProcessCache returns a processCache over platform
+func newProcessCache(platform tree.Platform, interval time.Duration) *processCache {
+	return &processCache{platform: platform, interval: interval}
+}
+
+// GetProcesses returns a copy of the cached process list, collecting it first
+// if it is missing or stale. Errors are not cached.
+func (c *processCache) GetProcesses() ([]tree.Process, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.now != nil {
+		now = c.now()
+	}
+
+	if c.processes == nil || now.Sub(c.collected) >= c.interval {
+		processes, err := c.platform.GetProcesses()
+		if err != nil {
+			return nil, err
+		}
+		c.processes = processes
+		c.collected = now
+	}
+
+	// Return a copy, since Proktree keeps pointers into the slice
+	return append([]tree.Process(nil), c.processes...), nil
+}
+
+// apiHandler serves the process tree as JSON:
+//
+//	/tree                          Trees of processes matching the CLI filters
+//	/tree?pid=…&user=…&string=…    The same, further filtered
+//	/process/{pid}                 A process and its descendants, as a tree
+//	/process/{pid}/ancestors       A process's ancestors, parent first
+//	/process/{pid}/descendants     A process's descendants, breadth first
+func apiHandler(cli CLI, platform tree.Platform) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/tree", func(w http.ResponseWriter, r *http.Request) {
+		pt, err := snapshot(cli, platform)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		filter, err := queryFilter(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		pt.tree = pt.tree.Filter(filter)
+
+		nodes := []*jsonNode{}
+		for _, rootPid := range pt.tree.Roots() {
+			nodes = append(nodes, pt.buildJSONNode(rootPid))
+		}
+		writeJSON(w, nodes)
+	})
+
+	mux.HandleFunc("/process/", func(w http.ResponseWriter, r *http.Request) {
+		// Paths are /process/{pid} or /process/{pid}/{relation}
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/process/"), "/")
+		if len(parts) > 2 {
+			http.NotFound(w, r)
+			return
+		}
+		pid, err := strconv.Atoi(parts[0])
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid pid: %s", parts[0]), http.StatusBadRequest)
+			return
+		}
+
+		pt, err := snapshot(cli, platform)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !pt.tree.Visible(pid) {
+			http.Error(w, fmt.Sprintf("no such process: %d", pid), http.StatusNotFound)
+			return
+		}
+
+		var related []int
+		switch {
+		case len(parts) == 1:
+			writeJSON(w, pt.buildJSONNode(pid))
+			return
+		case parts[1] == "ancestors":
+			related = pt.tree.Ancestors(pid)
+		case parts[1] == "descendants":
+			related = pt.tree.Descendants(pid)
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		nodes := []*jsonNode{}
+		for _, relatedPid := range related {
+			nodes = append(nodes, pt.jsonProcess(relatedPid))
+		}
+		writeJSON(w, nodes)
+	})
+
+	return mux
+}
+
+// queryFilter builds a filter from the pid, user, string and
+// string-insensitive query parameters, each of which may repeat
+func queryFilter(query url.Values) (tree.Filter, error) {
+	filter := tree.Filter{
+		Users:              query["user"],
+		Strings:            query["string"],
+		StringsInsensitive: query["string-insensitive"],
+	}
+	for _, pidStr := range query["pid"] {
+		pid, err := strconv.Atoi(pidStr)
+		if err != nil {
+			return filter, fmt.Errorf("invalid pid: %s", pidStr)
+		}
+		filter.PIDs = append(filter.PIDs, pid)
+	}
+	return filter, nil
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
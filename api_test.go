@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+// countingPlatform counts calls to GetProcesses
+type countingPlatform struct {
+	fakePlatform
+	calls int
+}
+
+func (c *countingPlatform) GetProcesses() ([]tree.Process, error) {
+	c.calls++
+	return c.fakePlatform.GetProcesses()
+}
+
+func TestProcessCache(t *testing.T) {
+	platform := &countingPlatform{fakePlatform: fakePlatform{processes: metricsTestProcesses()}}
+	now := time.Unix(1750000000, 0)
+	cache := newProcessCache(platform, 5*time.Second)
+	cache.now = func() time.Time { return now }
+
+	for _, step := range []struct {
+		advance time.Duration
+		calls   int
+	}{
+		{0, 1},               // First call collects
+		{4 * time.Second, 1}, // Still fresh
+		{time.Second, 2},     // Stale after the interval
+		{time.Second, 2},
+	} {
+		now = now.Add(step.advance)
+		processes, err := cache.GetProcesses()
+		if err != nil {
+			t.Fatalf("GetProcesses() error: %v", err)
+		}
+		if len(processes) != 5 {
+			t.Errorf("GetProcesses() returned %d processes, want 5", len(processes))
+		}
+		if platform.calls != step.calls {
+			t.Errorf("platform called %d times, want %d", platform.calls, step.calls)
+		}
+	}
+
+	// Callers get their own copy
+	processes, _ := cache.GetProcesses()
+	processes[0].User = "mallory"
+	if again, _ := cache.GetProcesses(); again[0].User != "root" {
+		t.Errorf("cached process modified through a returned copy")
+	}
+}
+
+func TestAPIHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		cli    CLI
+		path   string
+		status int
+		pids   []int // PIDs in the response, depth first
+	}{
+		{
+			name:   "whole tree",
+			path:   "/tree",
+			status: 200,
+			pids:   []int{1, 10, 11, 12, 20},
+		},
+		{
+			name:   "tree filtered by query",
+			path:   "/tree?user=bob",
+			status: 200,
+			pids:   []int{1, 20},
+		},
+		{
+			name:   "query narrows the CLI filters",
+			cli:    CLI{SearchStrings: []string{"celery"}},
+			path:   "/tree?string=helper&pid=20",
+			status: 200,
+			pids:   []int{1, 10, 12},
+		},
+		{
+			name:   "tree with no match",
+			path:   "/tree?user=nobody",
+			status: 200,
+			pids:   nil,
+		},
+		{
+			name:   "invalid pid query",
+			path:   "/tree?pid=abc",
+			status: 400,
+		},
+		{
+			name:   "process subtree",
+			path:   "/process/10",
+			status: 200,
+			pids:   []int{10, 11, 12},
+		},
+		{
+			name:   "ancestors",
+			path:   "/process/12/ancestors",
+			status: 200,
+			pids:   []int{10, 1},
+		},
+		{
+			name:   "descendants",
+			path:   "/process/1/descendants",
+			status: 200,
+			pids:   []int{10, 20, 11, 12},
+		},
+		{
+			name:   "process hidden by the CLI filters",
+			cli:    CLI{Users: []string{"celery"}},
+			path:   "/process/20/ancestors",
+			status: 404,
+		},
+		{
+			name:   "unknown process",
+			path:   "/process/99/descendants",
+			status: 404,
+		},
+		{
+			name:   "invalid pid",
+			path:   "/process/abc/ancestors",
+			status: 400,
+		},
+		{
+			name:   "unknown relation",
+			path:   "/process/10/siblings",
+			status: 404,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := apiHandler(tt.cli, &fakePlatform{processes: metricsTestProcesses()})
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest("GET", tt.path, nil))
+
+			if recorder.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.status, recorder.Body.String())
+			}
+			if tt.status != 200 {
+				return
+			}
+			if ct := recorder.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+
+			// A single object is a subtree; lists are trees or flat lists
+			var nodes []*jsonNode
+			body := recorder.Body.Bytes()
+			if len(body) > 0 && body[0] == '{' {
+				var node jsonNode
+				if err := json.Unmarshal(body, &node); err != nil {
+					t.Fatalf("invalid JSON: %v", err)
+				}
+				nodes = append(nodes, &node)
+			} else if err := json.Unmarshal(body, &nodes); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+
+			var pids []int
+			var collect func(nodes []*jsonNode)
+			collect = func(nodes []*jsonNode) {
+				for _, n := range nodes {
+					pids = append(pids, n.PID)
+					collect(n.Children)
+				}
+			}
+			collect(nodes)
+
+			if !slices.Equal(pids, tt.pids) {
+				t.Errorf("PIDs = %v, want %v", pids, tt.pids)
+			}
+		})
+	}
+}
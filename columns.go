@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+// columns are the extra columns available with --columns, by name
+var columns = map[string]tree.Column{
+	"ni":     {Header: "NI", Value: func(p *tree.Process) string { return strconv.Itoa(p.Nice) }},
+	"sid":    {Header: "SID", Value: sessionValue(func(s *tree.Session) string { return strconv.Itoa(s.SID) })},
+	"pgid":   {Header: "PGID", Value: sessionValue(func(s *tree.Session) string { return strconv.Itoa(s.PGID) })},
+	"tty":    {Header: "TTY", Value: sessionValue(ttyName), Left: true},
+	"listen": {Header: "LISTEN", Value: listenPorts, Left: true},
+	"fds":    {Header: "FDS", Value: fdCount},
+	"pss":    {Header: "PSS", Value: memoryValue(func(m *tree.Memory) float64 { return m.PSSKB })},
+	"uss":    {Header: "USS", Value: memoryValue(func(m *tree.Memory) float64 { return m.USSKB })},
+	"swap":   {Header: "SWAP", Value: memoryValue(func(m *tree.Memory) float64 { return m.SwapKB })},
+	"exe":    {Header: "EXE", Value: func(p *tree.Process) string { return knownPath(p.Exe) }, Left: true},
+	"cwd":    {Header: "CWD", Value: func(p *tree.Process) string { return knownPath(p.Cwd) }, Left: true},
+	"root":   {Header: "ROOT", Value: func(p *tree.Process) string { return knownPath(p.Root) }, Left: true},
+}
+
+// envColumns returns a column for each environment variable, in order,
+// without duplicates
+func envColumns(keys []string) []tree.Column {
+	var selected []tree.Column
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		key := key
+		selected = append(selected, tree.Column{
+			Header: key,
+			Value: func(p *tree.Process) string {
+				if p.Env == nil {
+					return "?"
+				}
+				return p.Env[key]
+			},
+			Left: true,
+		})
+	}
+	return selected
+}
+
+// memoryValue returns a column value formatting the KB of memory selects, or
+// "?" if the memory of the process couldn't be read
+func memoryValue(kb func(m *tree.Memory) float64) func(p *tree.Process) string {
+	return func(p *tree.Process) string {
+		if p.Memory == nil {
+			return "?"
+		}
+		return tree.FormatRSS(kb(p.Memory))
+	}
+}
+
+// sessionValue returns a column value showing the session of a process, or
+// "?" if it couldn't be read
+func sessionValue(value func(s *tree.Session) string) func(p *tree.Process) string {
+	return func(p *tree.Process) string {
+		if p.Session == nil {
+			return "?"
+		}
+		return value(p.Session)
+	}
+}
+
+// ttyName returns the controlling terminal of a session, or "-" if none
+func ttyName(s *tree.Session) string {
+	if s.TTY == "" {
+		return "-"
+	}
+	return s.TTY
+}
+
+// knownPath returns path, or "?" if it's unknown
+func knownPath(path string) string {
+	if path == "" {
+		return "?"
+	}
+	return path
+}
+
+// fdCount returns the number of descriptors p has open, or "?" if they
+// couldn't be read
+func fdCount(p *tree.Process) string {
+	if p.Files == nil {
+		return "?"
+	}
+	return strconv.Itoa(len(p.Files))
+}
+
+// listenPorts returns the ports p listens on, e.g. "22,80,53/udp", or "?" if
+// its sockets couldn't be read
+func listenPorts(p *tree.Process) string {
+	if p.Sockets == nil {
+		return "?"
+	}
+
+	type port struct {
+		number int
+		udp    bool
+	}
+	var ports []port
+	seen := make(map[port]bool)
+	for _, socket := range p.Sockets {
+		if !socket.Listen || socket.Proto == "unix" {
+			continue
+		}
+		// IPv4 and IPv6 sockets on the same port are one port to the reader
+		key := port{socket.Port, strings.HasPrefix(socket.Proto, "udp")}
+		if !seen[key] {
+			seen[key] = true
+			ports = append(ports, key)
+		}
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].udp != ports[j].udp {
+			return !ports[i].udp
+		}
+		return ports[i].number < ports[j].number
+	})
+
+	var names []string
+	for _, port := range ports {
+		name := strconv.Itoa(port.number)
+		if port.udp {
+			name += "/udp"
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, ",")
+}
+
+// extraColumns returns the named extra columns, in order, without duplicates
+func (pt *Proktree) extraColumns(names []string) []tree.Column {
+	var selected []tree.Column
+	seen := make(map[string]bool)
+	for _, name := range names {
+		column, ok := columns[name]
+		if !ok {
+			column, ok = pt.contextColumns()[name]
+		}
+		if ok && !seen[name] {
+			seen[name] = true
+			selected = append(selected, column)
+		}
+	}
+	return selected
+}
+
+// contextColumns are the extra columns that depend on the options, or on the
+// tree once loaded, like those totaling each process and its visible
+// descendants
+func (pt *Proktree) contextColumns() map[string]tree.Column {
+	ioColumn := func(header string, write, subtree bool) tree.Column {
+		return tree.Column{Header: header, Value: func(p *tree.Process) string {
+			if !subtree {
+				if p.IO == nil {
+					return "?"
+				}
+				return pt.formatIO(pt.ioBytes(p.IO, write))
+			}
+			total, approximate := pt.subtreeIO(p.PID, write)
+			if approximate {
+				return "~" + pt.formatIO(total)
+			}
+			return pt.formatIO(total)
+		}}
+	}
+
+	return map[string]tree.Column{
+		"subcpu": {Header: "SUB%CPU", Value: func(p *tree.Process) string {
+			return fmt.Sprintf("%.1f", pt.subtreeCPU(p.PID))
+		}},
+		"subpss": {Header: "SUBPSS", Value: func(p *tree.Process) string {
+			kb, approximate := pt.subtreePSS(p.PID)
+			if approximate {
+				return "~" + tree.FormatRSS(kb)
+			}
+			return tree.FormatRSS(kb)
+		}},
+		"read":     ioColumn("READ", false, false),
+		"write":    ioColumn("WRITE", true, false),
+		"subread":  ioColumn("SUBREAD", false, true),
+		"subwrite": ioColumn("SUBWRITE", true, true),
+	}
+}
+
+// ioBytes returns the bytes read, or written if write is set, per second if
+// sampling, or else in total
+func (pt *Proktree) ioBytes(stats *tree.IO, write bool) float64 {
+	switch {
+	case pt.cli.Sample > 0 && write:
+		return stats.WriteRate
+	case pt.cli.Sample > 0:
+		return stats.ReadRate
+	case write:
+		return stats.WriteBytes
+	}
+	return stats.ReadBytes
+}
+
+// formatIO formats the bytes of ioBytes, e.g. "1.5M", or "1.5M/s" if sampling
+func (pt *Proktree) formatIO(bytes float64) string {
+	if pt.cli.Sample > 0 {
+		return tree.FormatBytes(bytes) + "/s"
+	}
+	return tree.FormatBytes(bytes)
+}
+
+// subtree returns pid and its visible descendants
+func (pt *Proktree) subtree(pid int) []*tree.Process {
+	var processes []*tree.Process
+	for _, pid := range append([]int{pid}, pt.tree.Descendants(pid)...) {
+		if p := pt.tree.Process(pid); p != nil {
+			processes = append(processes, p)
+		}
+	}
+	return processes
+}
+
+// subtreeCPU returns the CPU usage of pid and its visible descendants
+func (pt *Proktree) subtreeCPU(pid int) float64 {
+	total := 0.0
+	for _, p := range pt.subtree(pid) {
+		total += p.CPUPct
+	}
+	return total
+}
+
+// subtreePSS returns the PSS of pid and its visible descendants, which unlike
+// RSS counts shared pages once. Processes whose PSS couldn't be read count
+// their RSS instead, making the total approximate.
+func (pt *Proktree) subtreePSS(pid int) (float64, bool) {
+	total, approximate := 0.0, false
+	for _, p := range pt.subtree(pid) {
+		if p.Memory == nil {
+			total += p.RSSKB
+			approximate = true
+		} else {
+			total += p.Memory.PSSKB
+		}
+	}
+	return total, approximate
+}
+
+// subtreeIO returns the ioBytes of pid and its visible descendants, and
+// whether the total misses some whose I/O couldn't be read
+func (pt *Proktree) subtreeIO(pid int, write bool) (float64, bool) {
+	total, approximate := 0.0, false
+	for _, p := range pt.subtree(pid) {
+		if p.IO == nil {
+			approximate = true
+		} else {
+			total += pt.ioBytes(p.IO, write)
+		}
+	}
+	return total, approximate
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+func TestListenPorts(t *testing.T) {
+	tests := []struct {
+		name     string
+		sockets  []tree.Socket
+		expected string
+	}{
+		{"unreadable", nil, "?"},
+		{"none", []tree.Socket{}, ""},
+		{
+			name: "listening only, tcp first, deduplicated",
+			sockets: []tree.Socket{
+				{Proto: "udp", Local: "0.0.0.0:53", Port: 53, Listen: true},
+				{Proto: "tcp", Local: "0.0.0.0:80", Port: 80, Listen: true},
+				{Proto: "tcp6", Local: "[::]:80", Port: 80, Listen: true},
+				{Proto: "tcp", Local: "0.0.0.0:22", Port: 22, Listen: true},
+				{Proto: "tcp", Local: "10.0.0.1:22", Port: 22},
+				{Proto: "unix", Local: "/run/app.sock", Listen: true},
+			},
+			expected: "22,80,53/udp",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := listenPorts(&tree.Process{Sockets: tt.sockets}); got != tt.expected {
+				t.Errorf("listenPorts() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFDCount(t *testing.T) {
+	if got := fdCount(&tree.Process{}); got != "?" {
+		t.Errorf("fdCount() unreadable = %q, want ?", got)
+	}
+	if got := fdCount(&tree.Process{Files: []tree.File{{FD: 0, Path: "/dev/null"}, {FD: 1, Path: "pipe:[1]"}}}); got != "2" {
+		t.Errorf("fdCount() = %q, want 2", got)
+	}
+}
+
+func TestPathColumns(t *testing.T) {
+	p := &tree.Process{Exe: "/srv/app/bin/app (deleted)", Root: "/"}
+	for name, expected := range map[string]string{"exe": "/srv/app/bin/app (deleted)", "cwd": "?", "root": "/"} {
+		if got := columns[name].Value(p); got != expected {
+			t.Errorf("%s column = %q, want %q", name, got, expected)
+		}
+	}
+}
+
+func TestEnvColumns(t *testing.T) {
+	selected := envColumns([]string{"JOB_ID", "DEPLOY_SHA", "JOB_ID", ""})
+	if len(selected) != 2 || selected[0].Header != "JOB_ID" || selected[1].Header != "DEPLOY_SHA" {
+		t.Fatalf("envColumns() = %+v, want JOB_ID and DEPLOY_SHA", selected)
+	}
+
+	p := &tree.Process{Env: map[string]string{"JOB_ID": "1234"}}
+	if got := selected[0].Value(p); got != "1234" {
+		t.Errorf("JOB_ID column = %q, want 1234", got)
+	}
+	if got := selected[1].Value(p); got != "" {
+		t.Errorf("unset DEPLOY_SHA column = %q, want empty", got)
+	}
+	if got := selected[0].Value(&tree.Process{}); got != "?" {
+		t.Errorf("unreadable JOB_ID column = %q, want ?", got)
+	}
+}
+
+func TestMemoryColumns(t *testing.T) {
+	processes := []tree.Process{
+		{PID: 1, Command: "/sbin/init", RSSKB: 10240, Memory: &tree.Memory{PSSKB: 4096, USSKB: 2048}},
+		{PID: 100, PPID: 1, Command: "gunicorn master", RSSKB: 51200, Memory: &tree.Memory{PSSKB: 10240, USSKB: 5120, SwapKB: 1024}},
+		{PID: 101, PPID: 100, Command: "gunicorn worker", RSSKB: 51200, Memory: &tree.Memory{PSSKB: 20480, USSKB: 15360}},
+		{PID: 102, PPID: 100, Command: "gunicorn worker", RSSKB: 51200, Memory: &tree.Memory{PSSKB: 20480, USSKB: 15360}},
+		{PID: 200, PPID: 1, Command: "sshd", RSSKB: 8192}, // Unreadable
+	}
+	pt := newTestProktree(t, CLI{Columns: []string{"pss", "uss", "swap", "subpss"}}, processes)
+	values := func(pid int) []string {
+		var got []string
+		for _, column := range pt.renderer.Columns {
+			got = append(got, column.Value(pt.tree.Process(pid)))
+		}
+		return got
+	}
+
+	tests := []struct {
+		pid      int
+		expected []string
+	}{
+		{100, []string{"10.0M", "5.0M", "1.0M", "50.0M"}}, // Not the 150M RSS of the workers
+		{101, []string{"20.0M", "15.0M", "0.0M", "20.0M"}},
+		{200, []string{"?", "?", "?", "~8.0M"}},
+		{1, []string{"4.0M", "2.0M", "0.0M", "~62.0M"}}, // sshd counts its RSS
+	}
+	for _, tt := range tests {
+		if got := values(tt.pid); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("PID %d columns = %q, want %q", tt.pid, got, tt.expected)
+		}
+	}
+}
+
+func TestNewPlatform(t *testing.T) {
+	native := &sequencePlatform{lists: [][]tree.Process{killTestProcesses()}}
+
+	if platform := newPlatform(CLI{}, native, nil); platform != tree.Platform(native) {
+		t.Errorf("newPlatform() wrapped the platform with no details needed")
+	}
+	for _, cli := range []CLI{{Ports: []int{80}}, {Columns: []string{"listen"}}, {Files: []string{"/tmp/lock"}}, {ShowFDs: true}, {Columns: []string{"fds"}}, {Columns: []string{"ni", "cwd"}}, {Columns: []string{"subpss"}}, {Columns: []string{"subwrite"}}, {Output: "folded", Weight: "read"}, {Output: "html"}, {TTYs: []string{"pts/3"}}, {BySession: true}, {Orphans: true}, {Env: []string{"JOB_ID"}}, {ShowEnv: []string{"JOB_ID"}}} {
+		if platform := newPlatform(cli, native, nil); platform == tree.Platform(native) {
+			t.Errorf("newPlatform(%+v) didn't add sockets", cli)
+		}
+	}
+
+	// Sampling reads I/O itself
+	platform := newPlatform(CLI{Sample: time.Second, Columns: []string{"read"}}, native, nil)
+	if sampling, ok := platform.(*samplingPlatform); !ok || !sampling.io {
+		t.Errorf("newPlatform() with --sample = %#v, want I/O sampled", platform)
+	}
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/kong"
+)
+
+// config holds the values read from a config file. Top-level keys are global
+// flags; tables are named after commands, e.g. [kill], or are profiles, e.g.
+// [profiles.web], each holding global flags.
+type config struct {
+	path     string
+	values   map[string]any
+	commands map[string]map[string]any
+	profiles map[string]map[string]any
+}
+
+// defaultConfigPath returns $XDG_CONFIG_HOME/proktree/config.toml, or
+// ~/.config/proktree/config.toml if XDG_CONFIG_HOME is unset
+func defaultConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "proktree", "config.toml")
+}
+
+// BeforeResolve loads the config file, so that its values fill in flags not
+// given on the command line
+func (cli *CLI) BeforeResolve(ctx *kong.Context) error {
+	path, explicit := commandLineFlag(ctx, "config")
+	if !explicit {
+		path = defaultConfigPath()
+	}
+
+	var cfg *config
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			if cfg, err = parseConfig(path, string(data)); err != nil {
+				return err
+			}
+			if err := cfg.validate(ctx.Model.Node); err != nil {
+				return err
+			}
+		case errors.Is(err, os.ErrNotExist) && !explicit:
+			// No config file is fine
+		default:
+			return fmt.Errorf("config: %v", err)
+		}
+	}
+
+	profile, _ := commandLineFlag(ctx, "profile")
+	if profile == "" && cfg != nil {
+		profile, _ = cfg.values["profile"].(string)
+	}
+	if profile != "" {
+		if cfg == nil || cfg.profiles[profile] == nil {
+			return fmt.Errorf("unknown profile: %s", profile)
+		}
+	}
+
+	if cfg != nil {
+		ctx.AddResolver(cfg.resolver(profile))
+	}
+	return nil
+}
+
+// commandLineFlag returns the value of a global string flag, and whether it
+// was given on the command line
+func commandLineFlag(ctx *kong.Context, name string) (string, bool) {
+	for _, trace := range ctx.Path {
+		if trace.Flag != nil && trace.Flag.Name == name {
+			value, _ := ctx.FlagValue(trace.Flag).(string)
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// resolver returns a kong resolver for the config values, preferring those of
+// profile, if set, for global flags
+func (cfg *config) resolver(profile string) kong.Resolver {
+	return kong.ResolverFunc(func(ctx *kong.Context, parent *kong.Path, flag *kong.Flag) (any, error) {
+		var tables []map[string]any
+		if parent.Command != nil {
+			tables = append(tables, cfg.commands[parent.Command.Name])
+		} else {
+			tables = append(tables, cfg.profiles[profile], cfg.values)
+		}
+
+		// Of flags that exclude each other, like the output modes, only those
+		// of the first to set any apply: the command line, then the profile,
+		// then the file
+		group := xorGroup(ctx, flag)
+		if len(group) > 0 {
+			for _, trace := range ctx.Path {
+				if trace.Flag != nil && group[trace.Flag.Name] {
+					return nil, nil
+				}
+			}
+		}
+		for _, table := range tables {
+			if value, ok := table[flag.Name]; ok {
+				return flagConfigValue(flag, value), nil
+			}
+			for name := range group {
+				if _, ok := table[name]; ok {
+					return nil, nil
+				}
+			}
+		}
+		return nil, nil
+	})
+}
+
+// xorGroup returns the names of the other flags sharing an xor group with flag
+func xorGroup(ctx *kong.Context, flag *kong.Flag) map[string]bool {
+	group := map[string]bool{}
+	for _, other := range ctx.Flags() {
+		if other.Name == flag.Name {
+			continue
+		}
+		for _, xor := range other.Xor {
+			for _, own := range flag.Xor {
+				if xor == own {
+					group[other.Name] = true
+				}
+			}
+		}
+	}
+	return group
+}
+
+// flagConfigValue adapts a config value to the type of flag, so that e.g.
+// pid = [123, 456] fills a list of strings
+func flagConfigValue(flag *kong.Flag, value any) any {
+	list, ok := value.([]any)
+	if !ok || flag.Target.Kind() != reflect.Slice || flag.Target.Type().Elem().Kind() != reflect.String {
+		return value
+	}
+	strs := make([]any, len(list))
+	for i, v := range list {
+		strs[i] = fmt.Sprint(v)
+	}
+	return strs
+}
+
+// validate checks that every key names a flag, of the command for command
+// tables, and global otherwise
+func (cfg *config) validate(app *kong.Node) error {
+	commands := map[string]*kong.Node{}
+	for _, child := range app.Children {
+		commands[child.Name] = child
+	}
+
+	if err := checkConfigKeys(cfg.path, "", cfg.values, app); err != nil {
+		return err
+	}
+	for name, table := range cfg.commands {
+		command, ok := commands[name]
+		if !ok {
+			return fmt.Errorf("%s: unknown table [%s]", cfg.path, name)
+		}
+		if err := checkConfigKeys(cfg.path, name, table, command); err != nil {
+			return err
+		}
+	}
+	for name, table := range cfg.profiles {
+		if err := checkConfigKeys(cfg.path, "profiles."+name, table, app, "profile"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkConfigKeys checks that every key of table is a flag of node, other
+// than those excluded
+func checkConfigKeys(path, tableName string, table map[string]any, node *kong.Node, excluded ...string) error {
+	known := map[string]bool{}
+	for _, flag := range node.Flags {
+		known[flag.Name] = true
+	}
+	// Choosing a config file from a config file makes no sense, nor does help
+	for _, name := range append(excluded, "config", "help") {
+		delete(known, name)
+	}
+
+	keys := make([]string, 0, len(table))
+	for key := range table {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !known[key] {
+			if tableName != "" {
+				return fmt.Errorf("%s: unknown option %s in [%s]", path, key, tableName)
+			}
+			return fmt.Errorf("%s: unknown option %s", path, key)
+		}
+	}
+	return nil
+}
+
+// parseConfig parses a config file, written in a subset of TOML: tables,
+// key = value pairs, strings, integers, floats, booleans, arrays and comments
+func parseConfig(path, data string) (*config, error) {
+	cfg := &config{
+		path:     path,
+		values:   map[string]any{},
+		commands: map[string]map[string]any{},
+		profiles: map[string]map[string]any{},
+	}
+
+	table := cfg.values
+	for n, line := range strings.Split(data, "\n") {
+		fail := func(format string, args ...any) error {
+			return fmt.Errorf("%s:%d: %s", path, n+1, fmt.Sprintf(format, args...))
+		}
+
+		line = strings.TrimSpace(stripComment(line))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fail("invalid table header: %s", line)
+			}
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			parts := strings.Split(name, ".")
+			for _, part := range parts {
+				if !validConfigKey(part) {
+					return nil, fail("invalid table name: %s", name)
+				}
+			}
+
+			var tables map[string]map[string]any
+			switch {
+			case len(parts) == 1 && parts[0] != "profiles":
+				tables = cfg.commands
+			case len(parts) == 2 && parts[0] == "profiles":
+				tables, name = cfg.profiles, parts[1]
+			default:
+				return nil, fail("unknown table [%s]", name)
+			}
+			if tables[name] != nil {
+				return nil, fail("duplicate table [%s]", strings.Join(parts, "."))
+			}
+			table = map[string]any{}
+			tables[name] = table
+			continue
+		}
+
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fail("expected key = value: %s", line)
+		}
+		key = strings.TrimSpace(key)
+		if !validConfigKey(key) {
+			return nil, fail("invalid key: %s", key)
+		}
+		// Keys may be written like the flags, or in snake_case
+		key = strings.ReplaceAll(key, "_", "-")
+		if _, ok := table[key]; ok {
+			return nil, fail("duplicate key: %s", key)
+		}
+
+		value, rest, err := parseConfigValue(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fail("%v", err)
+		}
+		if strings.TrimSpace(rest) != "" {
+			return nil, fail("unexpected text after value: %s", rest)
+		}
+		table[key] = value
+	}
+
+	return cfg, nil
+}
+
+// stripComment removes a # comment from a line, outside of strings
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// validConfigKey reports whether key is a bare TOML key
+func validConfigKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, c := range key {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// parseConfigValue parses the value at the start of s, returning it and the
+// rest of s
+func parseConfigValue(s string) (any, string, error) {
+	switch {
+	case s == "":
+		return nil, "", fmt.Errorf("missing value")
+
+	case s[0] == '"':
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				str, err := strconv.Unquote(s[:i+1])
+				if err != nil {
+					return nil, "", fmt.Errorf("invalid string: %s", s[:i+1])
+				}
+				return str, s[i+1:], nil
+			}
+		}
+		return nil, "", fmt.Errorf("unterminated string: %s", s)
+
+	case s[0] == '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return nil, "", fmt.Errorf("unterminated string: %s", s)
+		}
+		return s[1 : end+1], s[end+2:], nil
+
+	case s[0] == '[':
+		list := []any{}
+		rest := strings.TrimSpace(s[1:])
+		for !strings.HasPrefix(rest, "]") {
+			value, after, err := parseConfigValue(rest)
+			if err != nil {
+				return nil, "", err
+			}
+			list = append(list, value)
+			rest = strings.TrimSpace(after)
+			if strings.HasPrefix(rest, ",") {
+				rest = strings.TrimSpace(rest[1:])
+			} else if !strings.HasPrefix(rest, "]") {
+				return nil, "", fmt.Errorf("expected , or ] in array: %s", s)
+			}
+		}
+		return list, rest[1:], nil
+	}
+
+	// Bare values end at the next separator
+	end := strings.IndexAny(s, ", ]")
+	if end < 0 {
+		end = len(s)
+	}
+	word, rest := s[:end], s[end:]
+	switch word {
+	case "true":
+		return true, rest, nil
+	case "false":
+		return false, rest, nil
+	}
+	if n, err := strconv.ParseInt(strings.ReplaceAll(word, "_", ""), 10, 64); err == nil {
+		return n, rest, nil
+	}
+	if f, err := strconv.ParseFloat(strings.ReplaceAll(word, "_", ""), 64); err == nil {
+		return f, rest, nil
+	}
+	return nil, "", fmt.Errorf("invalid value: %s (strings need quotes)", word)
+}
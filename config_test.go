@@ -0,0 +1,219 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+const testConfig = `# Defaults for every run
+long-users = true
+indent = 4
+user = ["deploy", "www-data"]
+
+[kill]
+signal = "KILL"   # No second chances
+
+[profiles.web]
+string = ["nginx", 'php-fpm']
+columns = ["ni"]
+format = '{{.PID}} {{.Command}}'
+
+[profiles.build]
+pid = [1234]
+long_commands = true
+
+[profiles.quiet]
+quiet = true
+`
+
+// parseTestArgs parses args as main does, with the config dir set to dir
+func parseTestArgs(t *testing.T, dir string, args ...string) (CLI, string, error) {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	var cli CLI
+	parser, err := kong.New(&cli, kong.Name("proktree"))
+	if err != nil {
+		t.Fatalf("kong.New() error: %v", err)
+	}
+	ctx, err := parser.Parse(args)
+	if err != nil {
+		return cli, "", err
+	}
+	return cli, ctx.Command(), nil
+}
+
+// writeTestConfig writes config.toml into a new config dir, returning the dir
+func writeTestConfig(t *testing.T, data string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "proktree"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "proktree", "config.toml"), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestConfigFile(t *testing.T) {
+	dir := writeTestConfig(t, testConfig)
+	modeConfig := filepath.Join(writeTestConfig(t, "count = true\n[profiles.quiet]\nquiet = true\n"), "proktree", "config.toml")
+
+	tests := []struct {
+		name    string
+		args    []string
+		check   func(cli CLI) bool
+		command string
+	}{
+		{
+			name: "defaults from the file",
+			check: func(cli CLI) bool {
+				return cli.ShowFullUser && cli.Indent == 4 && reflect.DeepEqual(cli.Users, []string{"deploy", "www-data"})
+			},
+		},
+		{
+			name: "flags override the file",
+			args: []string{"--indent", "2", "-u", "root"},
+			check: func(cli CLI) bool {
+				return cli.ShowFullUser && cli.Indent == 2 && reflect.DeepEqual(cli.Users, []string{"root"})
+			},
+		},
+		{
+			name: "profile over the file",
+			args: []string{"--profile", "web"},
+			check: func(cli CLI) bool {
+				return reflect.DeepEqual(cli.SearchStrings, []string{"nginx", "php-fpm"}) &&
+					reflect.DeepEqual(cli.Columns, []string{"ni"}) && cli.Format == "{{.PID}} {{.Command}}" && cli.Indent == 4
+			},
+		},
+		{
+			name:  "numbers in lists and snake_case keys",
+			args:  []string{"--profile", "build", "-p", "99"},
+			check: func(cli CLI) bool { return reflect.DeepEqual(cli.PIDs, []string{"99"}) && cli.ShowFullCommand },
+		},
+		{
+			name:  "output modes on the command line override the file",
+			args:  []string{"--config", modeConfig, "--pids-only"},
+			check: func(cli CLI) bool { return cli.PIDsOnly && !cli.Count },
+		},
+		{
+			name:  "output modes of a profile override the file",
+			args:  []string{"--config", modeConfig, "--profile", "quiet"},
+			check: func(cli CLI) bool { return cli.Quiet && !cli.Count },
+		},
+		{
+			name:  "output modes on the command line override a profile",
+			args:  []string{"--profile", "quiet", "--count"},
+			check: func(cli CLI) bool { return cli.Count && !cli.Quiet },
+		},
+		{
+			name:  "output modes from the file",
+			args:  []string{"--config", modeConfig},
+			check: func(cli CLI) bool { return cli.Count },
+		},
+		{
+			name:    "command tables",
+			args:    []string{"kill", "-p", "10"},
+			check:   func(cli CLI) bool { return cli.Kill.Signal == "KILL" && cli.Indent == 4 },
+			command: "kill",
+		},
+		{
+			name:    "command flags override command tables",
+			args:    []string{"kill", "-p", "10", "--signal", "HUP"},
+			check:   func(cli CLI) bool { return cli.Kill.Signal == "HUP" },
+			command: "kill",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli, command, err := parseTestArgs(t, dir, tt.args...)
+			if err != nil {
+				t.Fatalf("Parse(%v) error: %v", tt.args, err)
+			}
+			if !tt.check(cli) {
+				t.Errorf("Parse(%v) = %+v", tt.args, cli)
+			}
+			if tt.command == "" {
+				tt.command = "tree"
+			}
+			if command != tt.command {
+				t.Errorf("command = %q, want %q", command, tt.command)
+			}
+		})
+	}
+}
+
+func TestConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		args    []string
+		wantErr string
+	}{
+		{"unknown profile", testConfig, []string{"--profile", "db"}, "unknown profile: db"},
+		{"unknown option", "indnet = 4\n", nil, "config.toml: unknown option indnet"},
+		{"unknown option in command", "[kill]\nindent = 4\n", nil, "config.toml: unknown option indent in [kill]"},
+		{"unknown command", "[restart]\n", nil, "config.toml: unknown table [restart]"},
+		{"config from config", "config = 'other.toml'\n", nil, "config.toml: unknown option config"},
+		{"bad enum", "glyphs = 'fancy'\n", nil, "--glyphs must be one of"},
+		{"syntax", "\n\nindent 4\n", nil, "config.toml:3: expected key = value: indent 4"},
+		{"unquoted string", "theme = light\n", nil, "config.toml:1: invalid value: light (strings need quotes)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeTestConfig(t, tt.config)
+			_, _, err := parseTestArgs(t, dir, tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse(%v) error = %v, want %q", tt.args, err, tt.wantErr)
+			}
+		})
+	}
+
+	// A missing default config is fine, a missing explicit one isn't
+	if _, _, err := parseTestArgs(t, t.TempDir()); err != nil {
+		t.Errorf("Parse() with no config error: %v", err)
+	}
+	missing := filepath.Join(t.TempDir(), "missing.toml")
+	if _, _, err := parseTestArgs(t, t.TempDir(), "--config", missing); err == nil {
+		t.Errorf("Parse(--config %s) succeeded", missing)
+	}
+}
+
+func TestParseConfigValue(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`"a \"quoted\" string"`, `a "quoted" string`},
+		{`'C:\literal'`, `C:\literal`},
+		{`42`, int64(42)},
+		{`1_000`, int64(1000)},
+		{`2.5`, 2.5},
+		{`false`, false},
+		{`[]`, []any{}},
+		{`[ "a", 'b', 3, ]`, []any{"a", "b", int64(3)}},
+		{`[["nested"]]`, []any{[]any{"nested"}}},
+	}
+
+	for _, tt := range tests {
+		got, rest, err := parseConfigValue(tt.input)
+		if err != nil || rest != "" {
+			t.Errorf("parseConfigValue(%s) = %v, %q, %v", tt.input, got, rest, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("parseConfigValue(%s) = %#v, want %#v", tt.input, got, tt.expected)
+		}
+	}
+
+	if got := stripComment(`format = "# not a comment" # a comment`); got != `format = "# not a comment" ` {
+		t.Errorf("stripComment() = %q", got)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+// csvHeader names the columns written by printDelimited
+var csvHeader = []string{
+	"pid", "ppid", "depth", "matched", "user", "cpu_pct", "mem_pct",
+	"rss_kb", "start_time", "cpu_time_seconds", "command",
+	"nice", "exe", "cwd", "root", "pss_kb", "uss_kb", "swap_kb",
+	"read_bytes", "write_bytes", "read_bytes_per_second", "write_bytes_per_second",
+	"sid", "pgid", "tty",
+}
+
+// printDelimited writes one row per visible process, in tree order, separated by comma
+func (pt *Proktree) printDelimited(w io.Writer, comma rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	err := pt.tree.Walk(func(n tree.Node) error {
+		p := n.Process
+
+		// Raw units: RSS in KB, CPU time in seconds, start time in RFC 3339
+		startTime := ""
+		if p.StartTime != nil {
+			startTime = p.StartTime.Format(time.RFC3339)
+		}
+
+		record := []string{
+			strconv.Itoa(p.PID),
+			strconv.Itoa(p.PPID),
+			strconv.Itoa(n.Depth),
+			strconv.FormatBool(pt.tree.Matched(p.PID)),
+			p.User,
+			strconv.FormatFloat(p.CPUPct, 'f', -1, 64),
+			strconv.FormatFloat(p.MemPct, 'f', -1, 64),
+			strconv.FormatFloat(p.RSSKB, 'f', -1, 64),
+			startTime,
+			strconv.FormatFloat(p.CPUTime.Seconds(), 'f', -1, 64),
+			p.Command,
+			strconv.Itoa(p.Nice),
+			p.Exe,
+			p.Cwd,
+			p.Root,
+		}
+
+		// Details are empty unless read, as with --columns
+		raw := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+		memory := make([]string, 3)
+		if p.Memory != nil {
+			memory = []string{raw(p.Memory.PSSKB), raw(p.Memory.USSKB), raw(p.Memory.SwapKB)}
+		}
+		storage := make([]string, 4)
+		if p.IO != nil {
+			storage[0], storage[1] = raw(p.IO.ReadBytes), raw(p.IO.WriteBytes)
+			if pt.cli.Sample > 0 {
+				storage[2], storage[3] = raw(p.IO.ReadRate), raw(p.IO.WriteRate)
+			}
+		}
+		session := make([]string, 3)
+		if p.Session != nil {
+			session = []string{strconv.Itoa(p.Session.SID), strconv.Itoa(p.Session.PGID), p.Session.TTY}
+		}
+		record = append(append(append(record, memory...), storage...), session...)
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+func TestPrintDelimited(t *testing.T) {
+	started := time.Date(2025, 7, 15, 13, 10, 0, 0, time.UTC)
+
+	processes := []tree.Process{
+		{PID: 1, PPID: 0, User: "root", CPUPct: 0.5, MemPct: 0.1, RSSKB: 1024, CPUTime: 90 * time.Second, Command: "init"},
+		{PID: 10, PPID: 1, User: "alice", RSSKB: 2048.5, Nice: 5, StartTime: &started, Command: "bash -c \"echo a,b\"",
+			Exe: "/usr/bin/bash", Cwd: "/home/alice", Root: "/",
+			Memory:  &tree.Memory{PSSKB: 1500.5, USSKB: 1200, SwapKB: 0},
+			IO:      &tree.IO{ReadBytes: 4096, WriteBytes: 512, ReadRate: 100, WriteRate: 2.5},
+			Session: &tree.Session{SID: 10, PGID: 10, TTY: "pts/3"}},
+		{PID: 20, PPID: 1, User: "bob", Command: "vim"},
+	}
+
+	tests := []struct {
+		name     string
+		cli      CLI
+		comma    rune
+		expected []string
+	}{
+		{
+			name:  "csv without filters",
+			cli:   CLI{},
+			comma: ',',
+			expected: []string{
+				"pid,ppid,depth,matched,user,cpu_pct,mem_pct,rss_kb,start_time,cpu_time_seconds,command," +
+					"nice,exe,cwd,root,pss_kb,uss_kb,swap_kb,read_bytes,write_bytes,read_bytes_per_second,write_bytes_per_second,sid,pgid,tty",
+				"1,0,0,false,root,0.5,0.1,1024,,90,init,0,,,,,,,,,,,,,",
+				`10,1,1,false,alice,0,0,2048.5,2025-07-15T13:10:00Z,0,"bash -c ""echo a,b""",5,/usr/bin/bash,/home/alice,/,1500.5,1200,0,4096,512,,,10,10,pts/3`,
+				"20,1,1,false,bob,0,0,0,,0,vim,0,,,,,,,,,,,,,",
+			},
+		},
+		{
+			name:  "rates when sampling",
+			cli:   CLI{PIDs: []string{"10"}, Sample: time.Second},
+			comma: ',',
+			expected: []string{
+				"pid,ppid,depth,matched,user,cpu_pct,mem_pct,rss_kb,start_time,cpu_time_seconds,command," +
+					"nice,exe,cwd,root,pss_kb,uss_kb,swap_kb,read_bytes,write_bytes,read_bytes_per_second,write_bytes_per_second,sid,pgid,tty",
+				"1,0,0,false,root,0.5,0.1,1024,,90,init,0,,,,,,,,,,,,,",
+				`10,1,1,true,alice,0,0,2048.5,2025-07-15T13:10:00Z,0,"bash -c ""echo a,b""",5,/usr/bin/bash,/home/alice,/,1500.5,1200,0,4096,512,100,2.5,10,10,pts/3`,
+			},
+		},
+		{
+			name:  "tsv filtered by user",
+			cli:   CLI{Users: []string{"bob"}},
+			comma: '\t',
+			expected: []string{
+				"pid\tppid\tdepth\tmatched\tuser\tcpu_pct\tmem_pct\trss_kb\tstart_time\tcpu_time_seconds\tcommand\t" +
+					"nice\texe\tcwd\troot\tpss_kb\tuss_kb\tswap_kb\tread_bytes\twrite_bytes\tread_bytes_per_second\twrite_bytes_per_second\tsid\tpgid\ttty",
+				"1\t0\t0\tfalse\troot\t0.5\t0.1\t1024\t\t90\tinit\t0" + strings.Repeat("\t", 13),
+				"20\t1\t1\ttrue\tbob\t0\t0\t0\t\t0\tvim\t0" + strings.Repeat("\t", 13),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pt := newTestProktree(t, tt.cli, processes)
+
+			var buf strings.Builder
+			if err := pt.printDelimited(&buf, tt.comma); err != nil {
+				t.Fatalf("printDelimited() error: %v", err)
+			}
+
+			lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+			if len(lines) != len(tt.expected) {
+				t.Fatalf("Expected %d lines, got %d:\n%s", len(tt.expected), len(lines), buf.String())
+			}
+			for i, expected := range tt.expected {
+				if lines[i] != expected {
+					t.Errorf("Line %d mismatch:\ngot:      %q\nexpected: %q", i, lines[i], expected)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+// detailsPlatform adds the process details that ps doesn't give, such as
+// sockets, to the processes of a platform
+type detailsPlatform struct {
+	tree.Platform
+	files   bool
+	sockets bool
+	paths   bool
+	env     bool
+	memory  bool
+	io      bool
+	session bool
+
+	// Filters on details, by the details, e.g. "sockets": "--port"
+	filters map[string]string
+	warn    io.Writer       // Told once per filter about processes it can't see
+	warned  map[string]bool // Filters warned about
+}
+
+// newPlatform wraps platform to read the process details the CLI needs, warning
+// on warn when filters can't see every process
+func newPlatform(cli CLI, platform tree.Platform, warn io.Writer) tree.Platform {
+	pathColumns := slices.ContainsFunc(cli.Columns, func(name string) bool {
+		return name == "exe" || name == "cwd" || name == "root"
+	})
+	memoryColumns := slices.ContainsFunc(cli.Columns, func(name string) bool {
+		return name == "pss" || name == "uss" || name == "swap" || name == "subpss"
+	})
+	sessionColumns := slices.ContainsFunc(cli.Columns, func(name string) bool {
+		return name == "sid" || name == "pgid" || name == "tty"
+	})
+	ioNeeded := slices.ContainsFunc(cli.Columns, func(name string) bool {
+		return name == "read" || name == "write" || name == "subread" || name == "subwrite"
+	}) || cli.Output == "html" || cli.Output == "folded" && (cli.Weight == "read" || cli.Weight == "write")
+
+	// Sampling reads I/O itself, in both its readings
+	if cli.Sample > 0 {
+		platform = newSamplingPlatform(platform, cli.Sample, ioNeeded)
+	}
+
+	d := &detailsPlatform{
+		Platform: platform,
+		files:    len(cli.Files) > 0 || cli.ShowFDs || slices.Contains(cli.Columns, "fds"),
+		sockets:  len(cli.Ports) > 0 || slices.Contains(cli.Columns, "listen"),
+		paths:    pathColumns,
+		env:      len(cli.Env) > 0 || len(cli.ShowEnv) > 0,
+		memory:   memoryColumns,
+		io:       ioNeeded && cli.Sample == 0,
+		session:  sessionColumns || len(cli.TTYs) > 0 || cli.BySession || cli.Orphans || cli.MarkOrphans,
+		filters:  make(map[string]string),
+		warn:     warn,
+		warned:   make(map[string]bool),
+	}
+	if !d.files && !d.sockets && !d.paths && !d.env && !d.memory && !d.io && !d.session {
+		return platform
+	}
+
+	// Filters silently missing processes would mislead
+	if len(cli.Ports) > 0 {
+		d.filters["sockets"] = "--port"
+	}
+	if len(cli.Files) > 0 {
+		d.filters["open files"] = "--file"
+	}
+	if len(cli.Env) > 0 {
+		d.filters["environment"] = "--env"
+	}
+	return d
+}
+
+func (d *detailsPlatform) GetProcesses() ([]tree.Process, error) {
+	processes, err := d.Platform.GetProcesses()
+	if err != nil {
+		return nil, err
+	}
+
+	if d.files {
+		unreadable, err := tree.ReadFiles(processes)
+		if err != nil {
+			return nil, err
+		}
+		d.warnUnreadable(unreadable, "open files")
+	}
+	if d.sockets {
+		unreadable, err := tree.ReadSockets(processes)
+		if err != nil {
+			return nil, err
+		}
+		d.warnUnreadable(unreadable, "sockets")
+	}
+	if d.env {
+		unreadable, err := tree.ReadEnv(processes)
+		if err != nil {
+			return nil, err
+		}
+		d.warnUnreadable(unreadable, "environment")
+	}
+	if d.paths {
+		if _, err := tree.ReadPaths(processes); err != nil {
+			return nil, err
+		}
+	}
+	if d.memory {
+		if _, err := tree.ReadMemory(processes); err != nil {
+			return nil, err
+		}
+	}
+	if d.io {
+		if _, err := tree.ReadIO(processes); err != nil {
+			return nil, err
+		}
+	}
+	if d.session {
+		if _, err := tree.ReadSessions(processes); err != nil {
+			return nil, err
+		}
+	}
+	return processes, nil
+}
+
+// warnUnreadable tells, once, that a filter on details couldn't see some processes
+func (d *detailsPlatform) warnUnreadable(n int, details string) {
+	flag, filtered := d.filters[details]
+	if n == 0 || !filtered || d.warn == nil || d.warned[flag] {
+		return
+	}
+	fmt.Fprintf(d.warn, "warning: couldn't read the %s of %s, which %s can't match; run as root to see them all\n",
+		details, processCount(n), flag)
+	d.warned[flag] = true
+}
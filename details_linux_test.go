@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+func TestWarnUnreadable(t *testing.T) {
+	// Processes that have exited can't be read; sockets count them as denied,
+	// the environment doesn't
+	gone := []tree.Process{
+		{PID: 1 << 30, PPID: 0, User: "root", Command: "gone"},
+		{PID: 1<<30 + 1, PPID: 1 << 30, User: "root", Command: "also gone"},
+	}
+
+	tests := []struct {
+		name     string
+		cli      CLI
+		expected string
+	}{
+		{"env filter", CLI{Env: []string{"JOB_ID"}}, ""},
+		{"port filter", CLI{Ports: []int{8080}}, "warning: couldn't read the sockets of 2 processes, which --port can't match; run as root to see them all\n"},
+		{"columns only", CLI{ShowEnv: []string{"JOB_ID"}, Columns: []string{"fds"}}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warnings strings.Builder
+			platform := newPlatform(tt.cli, &sequencePlatform{lists: [][]tree.Process{gone}}, &warnings)
+			for i := 0; i < 2; i++ {
+				processes, err := platform.GetProcesses()
+				if err != nil {
+					t.Fatalf("GetProcesses() error: %v", err)
+				}
+				if len(processes) != len(gone) {
+					t.Errorf("GetProcesses() = %d processes, want %d; unreadable ones must not be dropped", len(processes), len(gone))
+				}
+			}
+			// Warned once, however many times processes are listed
+			if warnings.String() != tt.expected {
+				t.Errorf("warnings = %q, want %q", warnings.String(), tt.expected)
+			}
+		})
+	}
+}
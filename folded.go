@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+// foldedWeight returns a process's weight for folded-stack output, as an integer
+func (pt *Proktree) foldedWeight(p *tree.Process, weight string) int64 {
+	switch weight {
+	case "cpu":
+		// Hundredths of a percent, since flame graph tools expect integer counts
+		return int64(math.Round(p.CPUPct * 100))
+	case "time":
+		return int64(p.CPUTime.Seconds())
+	case "read", "write":
+		// Storage bytes, per second with --sample; nothing if unreadable
+		if p.IO == nil {
+			return 0
+		}
+		return int64(math.Round(pt.ioBytes(p.IO, weight == "write")))
+	default: // rss
+		return int64(math.Round(p.RSSKB))
+	}
+}
+
+// foldedFrame returns a process's frame name: the base name of its executable
+func foldedFrame(command string) string {
+	// Semicolons separate frames
+	return strings.ReplaceAll(commandName(command), ";", "_")
+}
+
+// printFolded writes one Brendan Gregg style folded stack per visible process,
+// e.g. "init;sshd;bash;make;cc1 123456", weighted by RSS, %CPU, CPU time or I/O
+func (pt *Proktree) printFolded(w io.Writer, weight string) error {
+	return pt.tree.Walk(func(n tree.Node) error {
+		p := n.Process
+		value := pt.foldedWeight(p, weight)
+		if value <= 0 {
+			return nil
+		}
+
+		// Frames run from the root down to the process itself
+		ancestors := pt.tree.Ancestors(p.PID)
+		frames := make([]string, 0, len(ancestors)+1)
+		for i := len(ancestors) - 1; i >= 0; i-- {
+			frames = append(frames, foldedFrame(pt.tree.Process(ancestors[i]).Command))
+		}
+		frames = append(frames, foldedFrame(p.Command))
+
+		_, err := fmt.Fprintf(w, "%s %d\n", strings.Join(frames, ";"), value)
+		return err
+	})
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+func TestFoldedFrame(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected string
+	}{
+		{"/sbin/init splash", "init"},
+		{"-bash", "bash"},
+		{"sshd: alice [priv]", "sshd"},
+		{"/usr/lib/gcc/cc1 -quiet foo.c", "cc1"},
+		{"weird;name --flag", "weird_name"},
+		{"", "?"},
+	}
+
+	for _, tt := range tests {
+		if result := foldedFrame(tt.command); result != tt.expected {
+			t.Errorf("foldedFrame(%q) = %q, want %q", tt.command, result, tt.expected)
+		}
+	}
+}
+
+func TestPrintFolded(t *testing.T) {
+	processes := []tree.Process{
+		{PID: 1, PPID: 0, RSSKB: 1000, CPUPct: 0.1, CPUTime: 10 * time.Second, Command: "/sbin/init"},
+		{PID: 10, PPID: 1, RSSKB: 2000, CPUPct: 0, CPUTime: 0, Command: "/usr/sbin/sshd -D"},
+		{PID: 11, PPID: 10, RSSKB: 3000, CPUPct: 12.34, CPUTime: 90 * time.Second, Command: "-bash", IO: &tree.IO{ReadBytes: 4096}},
+		{PID: 12, PPID: 11, RSSKB: 123456, CPUPct: 99.5, CPUTime: 3600 * time.Second, Command: "make -j8", IO: &tree.IO{ReadBytes: 1 << 20, WriteBytes: 8192, WriteRate: 2048.4}},
+		{PID: 20, PPID: 1, RSSKB: 500, Command: "cron"},
+	}
+
+	tests := []struct {
+		name     string
+		weight   string
+		cli      CLI
+		expected []string
+	}{
+		{
+			name:   "rss",
+			weight: "rss",
+			expected: []string{
+				"init 1000",
+				"init;sshd 2000",
+				"init;sshd;bash 3000",
+				"init;sshd;bash;make 123456",
+				"init;cron 500",
+			},
+		},
+		{
+			name:   "cpu skips idle processes",
+			weight: "cpu",
+			expected: []string{
+				"init 10",
+				"init;sshd;bash 1234",
+				"init;sshd;bash;make 9950",
+			},
+		},
+		{
+			name:   "time with filter",
+			weight: "time",
+			cli:    CLI{SearchStrings: []string{"make"}},
+			expected: []string{
+				"init 10",
+				"init;sshd;bash 90",
+				"init;sshd;bash;make 3600",
+			},
+		},
+		{
+			name:   "write skips unreadable processes",
+			weight: "write",
+			expected: []string{
+				"init;sshd;bash;make 8192",
+			},
+		},
+		{
+			name:   "read",
+			weight: "read",
+			expected: []string{
+				"init;sshd;bash 4096",
+				"init;sshd;bash;make 1048576",
+			},
+		},
+		{
+			name:   "write rate when sampling",
+			weight: "write",
+			cli:    CLI{Sample: time.Second},
+			expected: []string{
+				"init;sshd;bash;make 2048",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pt := newTestProktree(t, tt.cli, processes)
+
+			var buf strings.Builder
+			if err := pt.printFolded(&buf, tt.weight); err != nil {
+				t.Fatalf("printFolded() error: %v", err)
+			}
+
+			lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+			if !slices.Equal(lines, tt.expected) {
+				t.Errorf("printFolded() =\n%s\nwant:\n%s", strings.Join(lines, "\n"), strings.Join(tt.expected, "\n"))
+			}
+		})
+	}
+}
@@ -2,9 +2,9 @@ module github.com/jeremywohl/proktree
 
 go 1.21
 
-require golang.org/x/term v0.23.0
-
 require (
-	github.com/alecthomas/kong v1.12.0 // indirect
-	golang.org/x/sys v0.23.0 // indirect
+	github.com/alecthomas/kong v1.12.0
+	golang.org/x/term v0.23.0
 )
+
+require golang.org/x/sys v0.23.0 // indirect
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+	"os"
+	"time"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+// jsonNode is a process as JSON, in the HTML report and the serve API
+type jsonNode struct {
+	PID      int         `json:"pid"`
+	PPID     int         `json:"ppid"`
+	User     string      `json:"user"`
+	CPUPct   float64     `json:"cpu"`
+	MemPct   float64     `json:"mem"`
+	RSSKB    float64     `json:"rss_kb"`
+	RSS      string      `json:"rss"`
+	Start    string      `json:"start"`     // As displayed in the tree
+	StartISO string      `json:"start_iso"` // RFC 3339, empty if unknown
+	CPUTime  float64     `json:"cpu_time"`  // Seconds
+	Time     string      `json:"time"`      // As displayed in the tree
+	Command  string      `json:"command"`
+	Matched  bool        `json:"matched"`
+	Children []*jsonNode `json:"children,omitempty"`
+
+	// Storage I/O, per second with --sample, absent if it wasn't read
+	ReadBytes  *float64 `json:"read_bytes,omitempty"`
+	Read       string   `json:"read,omitempty"` // As displayed in the READ column
+	WriteBytes *float64 `json:"write_bytes,omitempty"`
+	Write      string   `json:"write,omitempty"` // As displayed in the WRITE column
+}
+
+// htmlReport is the data handed to the HTML report template
+type htmlReport struct {
+	Title     string
+	Generated string
+	Data      template.JS
+}
+
+// buildJSONNode returns the jsonNode for pid, with its visible descendants
+func (pt *Proktree) buildJSONNode(pid int) *jsonNode {
+	node := pt.jsonProcess(pid)
+	for _, childPid := range pt.tree.Children(pid) {
+		node.Children = append(node.Children, pt.buildJSONNode(childPid))
+	}
+	return node
+}
+
+// jsonProcess returns the jsonNode for pid alone, without children
+func (pt *Proktree) jsonProcess(pid int) *jsonNode {
+	p := pt.tree.Process(pid)
+	node := &jsonNode{
+		PID:     p.PID,
+		PPID:    p.PPID,
+		User:    p.User,
+		CPUPct:  p.CPUPct,
+		MemPct:  p.MemPct,
+		RSSKB:   p.RSSKB,
+		RSS:     tree.FormatRSS(p.RSSKB),
+		Start:   pt.renderer.FormatStartTime(p.StartTime),
+		CPUTime: p.CPUTime.Seconds(),
+		Time:    tree.FormatCPUTime(p.CPUTime),
+		Command: p.Command,
+		Matched: pt.tree.Matched(p.PID),
+	}
+	if p.StartTime != nil {
+		node.StartISO = p.StartTime.Format(time.RFC3339)
+	}
+	if p.IO != nil {
+		read, write := pt.ioBytes(p.IO, false), pt.ioBytes(p.IO, true)
+		node.ReadBytes, node.Read = &read, pt.formatIO(read)
+		node.WriteBytes, node.Write = &write, pt.formatIO(write)
+	}
+	return node
+}
+
+// printHTML writes a self-contained HTML report of the visible process trees
+func (pt *Proktree) printHTML(w io.Writer) error {
+	nodes := []*jsonNode{}
+	for _, rootPid := range pt.tree.Roots() {
+		nodes = append(nodes, pt.buildJSONNode(rootPid))
+	}
+
+	// json.Marshal escapes <, > and &, so the result is safe inside a script element
+	data, err := json.Marshal(nodes)
+	if err != nil {
+		return err
+	}
+
+	title := "proktree"
+	if hostname, err := os.Hostname(); err == nil {
+		title = "proktree: " + hostname
+	}
+
+	return htmlTemplate.Execute(w, htmlReport{
+		Title:     title,
+		Generated: pt.now().Format(time.RFC1123),
+		Data:      template.JS(data),
+	})
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="generator" content="proktree">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 1.5em; color: #222; }
+h1 { font-size: 1.3em; margin: 0 0 0.2em 0; }
+.meta { color: #777; font-size: 0.85em; margin-bottom: 1em; }
+.controls { margin-bottom: 0.8em; }
+.controls input { font-size: 1em; padding: 0.3em 0.5em; width: 24em; }
+.controls button { font-size: 0.9em; margin-left: 0.4em; }
+table { border-collapse: collapse; font-family: Menlo, Consolas, monospace; font-size: 0.85em; width: 100%; }
+th { text-align: left; border-bottom: 2px solid #ccc; padding: 0.3em 0.6em; cursor: pointer; user-select: none; white-space: nowrap; }
+th.num, td.num { text-align: right; }
+th .dir { color: #999; }
+td { padding: 0.15em 0.6em; white-space: nowrap; vertical-align: top; }
+td.cmd { white-space: pre-wrap; word-break: break-all; }
+tr:hover td { background: #f4f7fb; }
+tr.matched td { background: #fff6d5; }
+tr.hit td.cmd { font-weight: bold; }
+.toggle { display: inline-block; width: 1.2em; cursor: pointer; color: #555; }
+.leaf { display: inline-block; width: 1.2em; color: #bbb; }
+table.noio .io { display: none; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="meta">Generated {{.Generated}} &middot; <span id="count"></span></div>
+<div class="controls">
+<input id="search" type="search" placeholder="Search PID, user or command" autofocus>
+<button id="expand">Expand all</button>
+<button id="collapse">Collapse all</button>
+</div>
+<table id="processes">
+<thead><tr>
+<th class="num" data-key="pid">PID <span class="dir"></span></th>
+<th data-key="user">USER <span class="dir"></span></th>
+<th class="num" data-key="cpu">%CPU <span class="dir"></span></th>
+<th class="num" data-key="mem">%MEM <span class="dir"></span></th>
+<th class="num" data-key="rss_kb">RSS <span class="dir"></span></th>
+<th data-key="start_iso">START <span class="dir"></span></th>
+<th class="num" data-key="cpu_time">TIME <span class="dir"></span></th>
+<th class="num io" data-key="read_bytes">READ <span class="dir"></span></th>
+<th class="num io" data-key="write_bytes">WRITE <span class="dir"></span></th>
+<th data-key="command">COMMAND <span class="dir"></span></th>
+</tr></thead>
+<tbody id="rows"></tbody>
+</table>
+<script type="application/json" id="proktree-data">{{.Data}}</script>
+<script>
+(function () {
+  "use strict";
+  var roots = JSON.parse(document.getElementById("proktree-data").textContent);
+  var collapsed = {};
+  var sortKey = null;
+  var sortAsc = true;
+  var query = "";
+  var total = 0;
+  var hasIO = false;
+
+  (function count(nodes) {
+    nodes.forEach(function (n) {
+      total++;
+      if (n.read_bytes !== undefined) { hasIO = true; }
+      count(n.children || []);
+    });
+  })(roots);
+  if (!hasIO) { document.getElementById("processes").className = "noio"; }
+
+  function text(s) { return document.createTextNode(s); }
+
+  function cell(cls, content) {
+    var td = document.createElement("td");
+    if (cls) { td.className = cls; }
+    if (typeof content === "string") { td.appendChild(text(content)); } else { td.appendChild(content); }
+    return td;
+  }
+
+  function compare(a, b) {
+    var x = a[sortKey], y = b[sortKey];
+    var r = 0;
+    // Unknown values, like unreadable I/O, sort lowest
+    if (x === undefined) { x = -1; }
+    if (y === undefined) { y = -1; }
+    if (typeof x === "number" && typeof y === "number") { r = x - y; }
+    else { r = String(x).localeCompare(String(y)); }
+    if (r === 0) { r = a.pid - b.pid; }
+    return sortAsc ? r : -r;
+  }
+
+  function ordered(nodes) {
+    if (!nodes) { return []; }
+    var copy = nodes.slice();
+    if (sortKey) { copy.sort(compare); }
+    return copy;
+  }
+
+  function matches(node) {
+    if (!query) { return false; }
+    return String(node.pid) === query ||
+      node.user.toLowerCase().indexOf(query) >= 0 ||
+      node.command.toLowerCase().indexOf(query) >= 0;
+  }
+
+  // visible reports whether the node or any descendant matches the search query
+  function visible(node, cache) {
+    if (!query) { return true; }
+    if (cache[node.pid] !== undefined) { return cache[node.pid]; }
+    var v = matches(node);
+    (node.children || []).forEach(function (c) { if (visible(c, cache)) { v = true; } });
+    cache[node.pid] = v;
+    return v;
+  }
+
+  function render() {
+    var tbody = document.getElementById("rows");
+    var cache = {};
+    var shown = 0;
+    var frag = document.createDocumentFragment();
+
+    function walk(nodes, depth) {
+      ordered(nodes).forEach(function (node) {
+        if (!visible(node, cache)) { return; }
+        shown++;
+        var tr = document.createElement("tr");
+        if (node.matched) { tr.className = "matched"; }
+        if (matches(node)) { tr.className += " hit"; }
+
+        var hasKids = node.children && node.children.length > 0;
+        var open = query ? true : !collapsed[node.pid];
+        var marker = document.createElement("span");
+        if (hasKids) {
+          marker.className = "toggle";
+          marker.appendChild(text(open ? "▾" : "▸"));
+          marker.addEventListener("click", function () {
+            collapsed[node.pid] = !collapsed[node.pid];
+            render();
+          });
+        } else {
+          marker.className = "leaf";
+          marker.appendChild(text("·"));
+        }
+        var cmd = document.createElement("span");
+        cmd.style.paddingLeft = (depth * 1.2) + "em";
+        cmd.appendChild(marker);
+        cmd.appendChild(text(node.command));
+
+        tr.appendChild(cell("num", String(node.pid)));
+        tr.appendChild(cell("", node.user));
+        tr.appendChild(cell("num", node.cpu.toFixed(1)));
+        tr.appendChild(cell("num", node.mem.toFixed(1)));
+        tr.appendChild(cell("num", node.rss));
+        tr.appendChild(cell("", node.start));
+        tr.appendChild(cell("num", node.time.trim()));
+        tr.appendChild(cell("num io", node.read || "?"));
+        tr.appendChild(cell("num io", node.write || "?"));
+        tr.appendChild(cell("cmd", cmd));
+        frag.appendChild(tr);
+
+        if (hasKids && open) { walk(node.children, depth + 1); }
+      });
+    }
+
+    walk(roots, 0);
+    tbody.textContent = "";
+    tbody.appendChild(frag);
+    document.getElementById("count").textContent = shown + " of " + total + " processes shown";
+
+    var ths = document.querySelectorAll("th[data-key]");
+    for (var i = 0; i < ths.length; i++) {
+      var dir = ths[i].querySelector(".dir");
+      dir.textContent = ths[i].getAttribute("data-key") === sortKey ? (sortAsc ? "▲" : "▼") : "";
+    }
+  }
+
+  function setAll(value) {
+    function walk(nodes) {
+      nodes.forEach(function (n) {
+        if (n.children && n.children.length > 0) { collapsed[n.pid] = value; walk(n.children); }
+      });
+    }
+    walk(roots);
+    render();
+  }
+
+  var ths = document.querySelectorAll("th[data-key]");
+  for (var i = 0; i < ths.length; i++) {
+    ths[i].addEventListener("click", function () {
+      var key = this.getAttribute("data-key");
+      if (sortKey === key) { sortAsc = !sortAsc; } else { sortKey = key; sortAsc = true; }
+      render();
+    });
+  }
+  document.getElementById("search").addEventListener("input", function () {
+    query = this.value.trim().toLowerCase();
+    render();
+  });
+  document.getElementById("expand").addEventListener("click", function () { setAll(false); });
+  document.getElementById("collapse").addEventListener("click", function () { setAll(true); });
+
+  render();
+})();
+</script>
+</body>
+</html>
+`))
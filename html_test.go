@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+func TestPrintHTML(t *testing.T) {
+	testNow := time.Date(2025, 7, 16, 10, 0, 0, 0, time.UTC)
+	started := time.Date(2025, 7, 15, 13, 10, 0, 0, time.UTC)
+
+	processes := []tree.Process{
+		{PID: 1, PPID: 0, User: "root", RSSKB: 1024, Command: "init"},
+		{PID: 10, PPID: 1, User: "alice", CPUPct: 2.5, StartTime: &started, CPUTime: 90 * time.Second, Command: "bash"},
+		{PID: 11, PPID: 10, User: "alice", Command: "echo '</script><script>alert(1)</script>'"},
+		{PID: 20, PPID: 1, User: "bob", Command: "vim"},
+	}
+
+	pt := newTestProktree(t, CLI{Users: []string{"alice"}, Indent: 2}, processes)
+	pt.renderer.Now = func() time.Time { return testNow }
+
+	var buf strings.Builder
+	if err := pt.printHTML(&buf); err != nil {
+		t.Fatalf("printHTML() error: %v", err)
+	}
+	output := buf.String()
+
+	if strings.Contains(output, "</script><script>alert(1)") {
+		t.Errorf("command text was not escaped inside the report")
+	}
+
+	// Extract and decode the embedded JSON
+	const marker = `<script type="application/json" id="proktree-data">`
+	start := strings.Index(output, marker)
+	if start < 0 {
+		t.Fatalf("embedded data not found in output")
+	}
+	data := output[start+len(marker):]
+	data = data[:strings.Index(data, "</script>")]
+
+	var roots []*jsonNode
+	if err := json.Unmarshal([]byte(data), &roots); err != nil {
+		t.Fatalf("embedded data is not valid JSON: %v", err)
+	}
+
+	if len(roots) != 1 || roots[0].PID != 1 {
+		t.Fatalf("expected single root PID 1, got %+v", roots)
+	}
+	if len(roots[0].Children) != 1 || roots[0].Children[0].PID != 10 {
+		t.Fatalf("expected bob's process to be filtered out, got %+v", roots[0].Children)
+	}
+
+	bash := roots[0].Children[0]
+	if !bash.Matched || roots[0].Matched {
+		t.Errorf("matched flags wrong: init=%v bash=%v", roots[0].Matched, bash.Matched)
+	}
+	if bash.StartISO != "2025-07-15T13:10:00Z" || bash.Start != "13:10" {
+		t.Errorf("start = %q / %q, want 13:10 / 2025-07-15T13:10:00Z", bash.Start, bash.StartISO)
+	}
+	if bash.CPUTime != 90 {
+		t.Errorf("cpu_time = %v, want 90", bash.CPUTime)
+	}
+	if len(bash.Children) != 1 || bash.Children[0].Command != processes[2].Command {
+		t.Errorf("expected descendant 11 under bash, got %+v", bash.Children)
+	}
+}
+
+func TestPrintHTMLIO(t *testing.T) {
+	processes := []tree.Process{
+		{PID: 1, PPID: 0, User: "root", Command: "init"},
+		{PID: 10, PPID: 1, User: "alice", IO: &tree.IO{ReadBytes: 2048, WriteBytes: 3 << 20, ReadRate: 512, WriteRate: 0}, Command: "rsync"},
+	}
+
+	tests := []struct {
+		name   string
+		cli    CLI
+		read   float64
+		write  float64
+		output [2]string
+	}{
+		{name: "totals", cli: CLI{Indent: 2}, read: 2048, write: 3 << 20, output: [2]string{"2.0K", "3.0M"}},
+		{name: "sampled", cli: CLI{Indent: 2, Sample: time.Second}, read: 512, write: 0, output: [2]string{"512B/s", "0B/s"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pt := newTestProktree(t, tt.cli, processes)
+			var buf strings.Builder
+			if err := pt.printHTML(&buf); err != nil {
+				t.Fatalf("printHTML() error: %v", err)
+			}
+			if !strings.Contains(buf.String(), `data-key="read_bytes"`) {
+				t.Errorf("report has no sortable READ column")
+			}
+
+			init := pt.jsonProcess(1)
+			if init.ReadBytes != nil || init.WriteBytes != nil {
+				t.Errorf("unreadable I/O = %v / %v, want absent", init.ReadBytes, init.WriteBytes)
+			}
+			rsync := pt.jsonProcess(10)
+			if rsync.ReadBytes == nil || *rsync.ReadBytes != tt.read || rsync.WriteBytes == nil || *rsync.WriteBytes != tt.write {
+				t.Fatalf("I/O = %v / %v, want %v / %v", rsync.ReadBytes, rsync.WriteBytes, tt.read, tt.write)
+			}
+			if rsync.Read != tt.output[0] || rsync.Write != tt.output[1] {
+				t.Errorf("I/O shown as %q / %q, want %q / %q", rsync.Read, rsync.Write, tt.output[0], tt.output[1])
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+// KillCmd holds the args of the kill subcommand
+type KillCmd struct {
+	Signal string `name:"signal" help:"Signal to send, by name or number, e.g. TERM, KILL, HUP or 9 (default: TERM)" default:"TERM"`
+	Yes    bool   `short:"y" name:"yes" help:"Don't ask for confirmation"`
+}
+
+// errSignalsUnsupported is returned by kill, stop and cont where processes
+// can't be signaled
+var errSignalsUnsupported = errors.New("signaling processes is unsupported on this platform")
+
+// parseSignal parses a signal name, with or without the SIG prefix, or number
+func parseSignal(name string) (syscall.Signal, error) {
+	if n, err := strconv.Atoi(name); err == nil && n > 0 {
+		return syscall.Signal(n), nil
+	}
+	if sig, ok := signals[strings.TrimPrefix(strings.ToUpper(name), "SIG")]; ok {
+		return sig, nil
+	}
+	return 0, usagef("unknown signal: %s", name)
+}
+
+// signalName returns the name of sig, e.g. SIGTERM, or its number if unnamed
+func signalName(sig syscall.Signal) string {
+	for name, s := range signals {
+		if s == sig {
+			return "SIG" + name
+		}
+	}
+	return strconv.Itoa(int(sig))
+}
+
+// runKill signals the processes matching the CLI filters and all their
+// descendants, after showing them and asking for confirmation on in
+func runKill(cli CLI, platform tree.Platform, in io.Reader, out io.Writer) error {
+	if !signalsSupported {
+		return errSignalsUnsupported
+	}
+	sig, err := parseSignal(cli.Kill.Signal)
+	if err != nil {
+		return err
+	}
+
+	pt, targets, err := subtreeTargets(cli, platform, "signal")
+	if err != nil {
+		return err
+	}
+
+	if err := pt.renderer.Render(out, tree.New(targets)); err != nil {
+		return err
+	}
+
+	if !cli.Kill.Yes {
+		prompt := fmt.Sprintf("Send %s to %s?", signalName(sig), processCount(len(targets)))
+		if ok, err := confirm(prompt, in, out); err != nil {
+			return err
+		} else if !ok {
+			fmt.Fprintln(out, "Nothing signaled")
+			return nil
+		}
+	}
+
+	// Leaves first, so parents can't respawn or reap children mid-way
+	return signalProcesses(out, platform, leavesFirst(targets), sig)
+}
+
+// subtreeTargets collects the processes matching the CLI filters and all their
+// descendants, in display order, refusing to target every process
+func subtreeTargets(cli CLI, platform tree.Platform, verb string) (*Proktree, []tree.Process, error) {
+	pt, err := snapshot(cli, platform)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !pt.tree.Filtered() {
+		return nil, nil, usagef("choose processes to %s, e.g. -p PID", verb)
+	}
+	targets := pt.subtreeProcesses()
+	if len(targets) == 0 {
+		return nil, nil, errNoMatch
+	}
+	return pt, targets, nil
+}
+
+// leavesFirst reverses processes in display order, so that every process
+// comes after its descendants
+func leavesFirst(processes []tree.Process) []tree.Process {
+	reversed := make([]tree.Process, len(processes))
+	for i, p := range processes {
+		reversed[len(processes)-1-i] = p
+	}
+	return reversed
+}
+
+// subtreeProcesses returns copies of the matched processes and all their
+// descendants, in display order. Ancestors of proktree are left out, so that
+// e.g. stop -u $USER doesn't freeze the shell it runs in.
+func (pt *Proktree) subtreeProcesses() []tree.Process {
+	var processes []tree.Process
+	add := func(p *tree.Process) {
+		if !pt.ancestors[p.PID] {
+			processes = append(processes, *p)
+		}
+	}
+	_ = pt.tree.Walk(func(n tree.Node) error {
+		if !pt.tree.Matched(n.Process.PID) {
+			return nil
+		}
+		// Everything below a match is included, so take the subtree and move on
+		add(n.Process)
+		for _, pid := range pt.tree.Descendants(n.Process.PID) {
+			add(pt.tree.Process(pid))
+		}
+		return tree.SkipChildren
+	})
+
+	// Descendants are breadth first; rebuild display order
+	var ordered []tree.Process
+	_ = tree.New(processes).Walk(func(n tree.Node) error {
+		ordered = append(ordered, *n.Process)
+		return nil
+	})
+	return ordered
+}
+
+// confirm asks a yes/no question on out and reads the answer from in
+func confirm(prompt string, in io.Reader, out io.Writer) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N] ", prompt)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+// signalProcesses sends sig to each process in order, reporting each on out
+func signalProcesses(out io.Writer, platform tree.Platform, processes []tree.Process, sig syscall.Signal) error {
+	return applyProcesses(out, platform, processes, signalName(sig), func(pid int) error {
+		return killProcess(pid, sig)
+	})
+}
+
+// skippedError is returned by an apply function of applyProcesses for a
+// process it left alone, giving the reason
+type skippedError string
+
+func (e skippedError) Error() string { return string(e) }
+
+// applyProcesses calls apply for each process in order, reporting each on out
+// with the action's description. Processes are first checked against a fresh
+// process list, and skipped if they exited or their PID now belongs to a
+// different process.
+func applyProcesses(out io.Writer, platform tree.Platform, processes []tree.Process, action string, apply func(pid int) error) error {
+	current, err := platform.GetProcesses()
+	if err != nil {
+		return fmt.Errorf("failed to get processes: %v", err)
+	}
+	byPid := make(map[int]tree.Process, len(current))
+	for _, p := range current {
+		byPid[p.PID] = p
+	}
+
+	failed := 0
+	for _, p := range processes {
+		now, ok := byPid[p.PID]
+		if !ok {
+			fmt.Fprintf(out, "%7d  skipped, exited\n", p.PID)
+			continue
+		}
+		if !sameProcess(p, now) {
+			fmt.Fprintf(out, "%7d  skipped, PID reused by %s\n", p.PID, commandName(now.Command))
+			continue
+		}
+		if err := apply(p.PID); err != nil {
+			var skipped skippedError
+			if errors.As(err, &skipped) {
+				fmt.Fprintf(out, "%7d  skipped, %s\n", p.PID, skipped)
+				continue
+			}
+			if errors.Is(err, syscall.ESRCH) {
+				fmt.Fprintf(out, "%7d  skipped, exited\n", p.PID)
+				continue
+			}
+			fmt.Fprintf(out, "%7d  failed: %v\n", p.PID, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(out, "%7d  %s %s\n", p.PID, action, commandName(p.Command))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed for %d of %s", failed, processCount(len(processes)))
+	}
+	return nil
+}
+
+// sameProcess reports whether two readings of a PID are the same process: by
+// start time, since commands can rewrite their arguments, or by command if the
+// start time is unknown
+func sameProcess(before, after tree.Process) bool {
+	if before.StartTime != nil && after.StartTime != nil {
+		return before.StartTime.Equal(*after.StartTime)
+	}
+	return before.StartTime == nil && after.StartTime == nil && before.Command == after.Command
+}
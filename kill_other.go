@@ -0,0 +1,22 @@
+//go:build !unix
+
+package main
+
+import "syscall"
+
+// signalsSupported reports whether processes can be signaled
+const signalsSupported = false
+
+// sigStop and sigCont have no equivalent here; stop and cont are unsupported
+const (
+	sigStop = syscall.Signal(0)
+	sigCont = syscall.Signal(0)
+)
+
+// signals is empty, as no signal can be sent
+var signals = map[string]syscall.Signal{}
+
+// killProcess is unsupported; tests replace it
+var killProcess = func(pid int, sig syscall.Signal) error {
+	return errSignalsUnsupported
+}
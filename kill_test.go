@@ -0,0 +1,225 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+// sequencePlatform returns each of its process lists in turn, repeating the last
+type sequencePlatform struct {
+	lists [][]tree.Process
+	calls int
+}
+
+func (s *sequencePlatform) GetProcesses() ([]tree.Process, error) {
+	list := s.lists[min(s.calls, len(s.lists)-1)]
+	s.calls++
+	return append([]tree.Process(nil), list...), nil
+}
+
+// signaled records the signals sent through killProcess during a test
+type signaled struct {
+	pid int
+	sig syscall.Signal
+}
+
+// recordSignals replaces killProcess for the duration of the test
+func recordSignals(t *testing.T, fail map[int]error) *[]signaled {
+	t.Helper()
+	var sent []signaled
+	original := killProcess
+	killProcess = func(pid int, sig syscall.Signal) error {
+		if err := fail[pid]; err != nil {
+			return err
+		}
+		sent = append(sent, signaled{pid, sig})
+		return nil
+	}
+	t.Cleanup(func() { killProcess = original })
+	return &sent
+}
+
+// runningUnder makes pid the parent of proktree for the duration of the test
+func runningUnder(t *testing.T, pid int) {
+	t.Helper()
+	original := parentPID
+	parentPID = func() int { return pid }
+	t.Cleanup(func() { parentPID = original })
+}
+
+func killTestProcesses() []tree.Process {
+	started := time.Unix(1750000000, 0)
+	at := func(offset int) *time.Time {
+		t := started.Add(time.Duration(offset) * time.Second)
+		return &t
+	}
+	return []tree.Process{
+		{PID: 1, PPID: 0, User: "root", StartTime: at(0), Command: "/sbin/init"},
+		{PID: 10, PPID: 1, User: "alice", StartTime: at(10), Command: "make -j2"},
+		{PID: 11, PPID: 10, User: "alice", StartTime: at(11), Command: "cc -c a.c"},
+		{PID: 12, PPID: 11, User: "alice", StartTime: at(12), Command: "cc1 a.c"},
+		{PID: 13, PPID: 10, User: "alice", StartTime: at(13), Command: "cc -c b.c"},
+		{PID: 20, PPID: 1, User: "bob", StartTime: at(20), Command: "vim"},
+	}
+}
+
+func TestParseSignal(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected syscall.Signal
+		wantErr  bool
+	}{
+		{"TERM", syscall.SIGTERM, false},
+		{"SIGKILL", syscall.SIGKILL, false},
+		{"hup", syscall.SIGHUP, false},
+		{"9", syscall.SIGKILL, false},
+		{"BOGUS", 0, true},
+		{"-1", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig, err := parseSignal(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSignal(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if sig != tt.expected {
+				t.Errorf("parseSignal(%q) = %v, want %v", tt.name, sig, tt.expected)
+			}
+		})
+	}
+
+	if name := signalName(syscall.SIGTERM); name != "SIGTERM" {
+		t.Errorf("signalName(SIGTERM) = %q", name)
+	}
+}
+
+func TestRunKill(t *testing.T) {
+	// PID 13 is replaced after listing, PID 12 exits, PID 11 can't be signaled
+	reused := killTestProcesses()
+	replacement := time.Unix(1760000000, 0)
+	reused[4] = tree.Process{PID: 13, PPID: 1, User: "carol", StartTime: &replacement, Command: "sleep 60"}
+	exited := append(reused[:3:3], reused[4:]...)
+
+	tests := []struct {
+		name     string
+		cli      CLI
+		lists    [][]tree.Process
+		input    string
+		fail     map[int]error
+		parent   int   // Parent of proktree, if in the list
+		expected []int // Signaled PIDs, in order
+		output   []string
+		wantErr  string
+	}{
+		{
+			name:     "confirmed, leaves first",
+			cli:      CLI{PIDs: []string{"10"}, Kill: KillCmd{Signal: "TERM"}},
+			input:    "y\n",
+			expected: []int{13, 12, 11, 10},
+			output:   []string{"make -j2", "cc1 a.c", "Send SIGTERM to 4 processes? [y/N] ", "     13  SIGTERM cc\n"},
+		},
+		{
+			name:   "declined",
+			cli:    CLI{PIDs: []string{"10"}, Kill: KillCmd{Signal: "TERM"}},
+			input:  "n\n",
+			output: []string{"Nothing signaled"},
+		},
+		{
+			name:   "no answer",
+			cli:    CLI{PIDs: []string{"10"}, Kill: KillCmd{Signal: "TERM"}},
+			output: []string{"Nothing signaled"},
+		},
+		{
+			name:     "yes flag, ancestors of the match untouched",
+			cli:      CLI{PIDs: []string{"11"}, Kill: KillCmd{Signal: "KILL", Yes: true}},
+			expected: []int{12, 11},
+			output:   []string{"     12  SIGKILL cc1\n"},
+		},
+		{
+			name:     "reused and exited PIDs skipped",
+			cli:      CLI{PIDs: []string{"10"}, Kill: KillCmd{Signal: "TERM", Yes: true}},
+			lists:    [][]tree.Process{killTestProcesses(), exited},
+			expected: []int{11, 10},
+			output:   []string{"     13  skipped, PID reused by sleep\n", "     12  skipped, exited\n"},
+		},
+		{
+			name:     "failures reported",
+			cli:      CLI{PIDs: []string{"10"}, Kill: KillCmd{Signal: "TERM", Yes: true}},
+			fail:     map[int]error{11: syscall.EPERM, 12: syscall.ESRCH},
+			expected: []int{13, 10},
+			output:   []string{"     11  failed: operation not permitted\n", "     12  skipped, exited\n"},
+			wantErr:  "failed for 1 of 4 processes",
+		},
+		{
+			name:     "ancestors of proktree untouched",
+			cli:      CLI{PIDs: []string{"10"}, Kill: KillCmd{Signal: "TERM", Yes: true}},
+			parent:   11,
+			expected: []int{13, 12},
+			output:   []string{"     13  SIGTERM cc\n     12  SIGTERM cc1\n"},
+		},
+		{
+			name:    "only ancestors of proktree match",
+			cli:     CLI{PIDs: []string{"11"}, Kill: KillCmd{Signal: "TERM", Yes: true}},
+			parent:  12,
+			wantErr: "no matching processes",
+		},
+		{
+			name:    "no filters",
+			cli:     CLI{Kill: KillCmd{Signal: "TERM", Yes: true}},
+			wantErr: "choose processes to signal, e.g. -p PID",
+		},
+		{
+			name:    "no match",
+			cli:     CLI{Users: []string{"nobody"}, Kill: KillCmd{Signal: "TERM", Yes: true}},
+			wantErr: "no matching processes",
+		},
+		{
+			name:    "unknown signal",
+			cli:     CLI{PIDs: []string{"10"}, Kill: KillCmd{Signal: "NOPE", Yes: true}},
+			wantErr: "unknown signal: NOPE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := recordSignals(t, tt.fail)
+			if tt.parent != 0 {
+				runningUnder(t, tt.parent)
+			}
+			lists := tt.lists
+			if lists == nil {
+				lists = [][]tree.Process{killTestProcesses()}
+			}
+
+			var out strings.Builder
+			err := runKill(tt.cli, &sequencePlatform{lists: lists}, strings.NewReader(tt.input), &out)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("runKill() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("runKill() error: %v", err)
+			}
+
+			var pids []int
+			for _, s := range *sent {
+				pids = append(pids, s.pid)
+			}
+			if !slices.Equal(pids, tt.expected) {
+				t.Errorf("signaled %v, want %v", pids, tt.expected)
+			}
+
+			for _, expected := range tt.output {
+				if !strings.Contains(out.String(), expected) {
+					t.Errorf("output missing %q:\n%s", expected, out.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+//go:build unix
+
+package main
+
+import "syscall"
+
+// signalsSupported reports whether processes can be signaled
+const signalsSupported = true
+
+// sigStop and sigCont pause and resume a process, for stop and cont
+const (
+	sigStop = syscall.SIGSTOP
+	sigCont = syscall.SIGCONT
+)
+
+// signals are the signals accepted by name, without the SIG prefix
+var signals = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+	"ALRM": syscall.SIGALRM,
+	"TERM": syscall.SIGTERM,
+	"STOP": syscall.SIGSTOP,
+	"CONT": syscall.SIGCONT,
+	"TSTP": syscall.SIGTSTP,
+}
+
+// killProcess sends a signal to a process; tests replace it
+var killProcess = func(pid int, sig syscall.Signal) error {
+	return syscall.Kill(pid, sig)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+// metricFamily is one Prometheus gauge and its help text
+type metricFamily struct {
+	name  string
+	help  string
+	value func(p *tree.Process) (float64, bool) // false if the value is unknown
+}
+
+// processMetrics are exported for every process shown by the filters
+var processMetrics = []metricFamily{
+	{"proktree_process_cpu_percent", "CPU usage percentage, as reported by ps, or measured over --sample.",
+		func(p *tree.Process) (float64, bool) { return p.CPUPct, true }},
+	{"proktree_process_memory_percent", "Memory usage percentage, as reported by ps.",
+		func(p *tree.Process) (float64, bool) { return p.MemPct, true }},
+	{"proktree_process_resident_memory_bytes", "Resident set size in bytes.",
+		func(p *tree.Process) (float64, bool) { return p.RSSKB * 1024, true }},
+	{"proktree_process_cpu_seconds", "Cumulative CPU time in seconds.",
+		func(p *tree.Process) (float64, bool) { return p.CPUTime.Seconds(), true }},
+	{"proktree_process_start_time_seconds", "Start time since the Unix epoch in seconds.",
+		func(p *tree.Process) (float64, bool) {
+			if p.StartTime == nil {
+				return 0, false
+			}
+			return float64(p.StartTime.Unix()), true
+		}},
+}
+
+// subtreeMetrics are summed over a matched process and all its descendants
+var subtreeMetrics = []metricFamily{
+	{"proktree_subtree_processes", "Number of processes in the subtree.",
+		func(p *tree.Process) (float64, bool) { return 1, true }},
+	{"proktree_subtree_cpu_percent", "Total CPU usage percentage of the subtree.",
+		func(p *tree.Process) (float64, bool) { return p.CPUPct, true }},
+	{"proktree_subtree_memory_percent", "Total memory usage percentage of the subtree.",
+		func(p *tree.Process) (float64, bool) { return p.MemPct, true }},
+	{"proktree_subtree_resident_memory_bytes", "Total resident set size of the subtree in bytes.",
+		func(p *tree.Process) (float64, bool) { return p.RSSKB * 1024, true }},
+	{"proktree_subtree_cpu_seconds", "Total cumulative CPU time of the subtree in seconds.",
+		func(p *tree.Process) (float64, bool) { return p.CPUTime.Seconds(), true }},
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// metricsHandler serves Prometheus metrics for the processes from platform
+func metricsHandler(cli CLI, platform tree.Platform) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pt, err := snapshot(cli, platform)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = pt.writeMetrics(w)
+	})
+}
+
+// subtreeRoots returns the PIDs whose subtrees are aggregated: the outermost
+// matched processes, or the root processes when there are no filters
+func (pt *Proktree) subtreeRoots() []int {
+	if !pt.tree.Filtered() {
+		return pt.tree.Roots()
+	}
+
+	var roots []int
+	for _, pid := range pt.tree.PIDs() {
+		if !pt.tree.Matched(pid) {
+			continue
+		}
+
+		// Skip matches nested under another match; their parent's total includes them
+		nested := false
+		for _, ancestor := range pt.tree.Ancestors(pid) {
+			if pt.tree.Matched(ancestor) {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			roots = append(roots, pid)
+		}
+	}
+	return roots
+}
+
+// writeMetrics writes Prometheus text-format gauges for the matched processes
+// and their descendants, and the totals of each matched subtree. Ancestors
+// shown only for context, like init, get no series.
+func (pt *Proktree) writeMetrics(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	roots := pt.subtreeRoots()
+	inSubtree := make(map[int]bool)
+	for _, rootPid := range roots {
+		inSubtree[rootPid] = true
+		for _, pid := range pt.tree.Descendants(rootPid) {
+			inSubtree[pid] = true
+		}
+	}
+	var processes []*tree.Process
+	_ = pt.tree.Walk(func(n tree.Node) error {
+		if inSubtree[n.Process.PID] {
+			processes = append(processes, n.Process)
+		}
+		return nil
+	})
+
+	for _, m := range processMetrics {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s gauge\n", m.name, m.help, m.name)
+		for _, p := range processes {
+			if v, ok := m.value(p); ok {
+				fmt.Fprintf(bw, "%s{pid=\"%d\",ppid=\"%d\",user=\"%s\",command=\"%s\"} %s\n",
+					m.name, p.PID, p.PPID, labelEscaper.Replace(p.User),
+					labelEscaper.Replace(commandName(p.Command)), formatMetricValue(v))
+			}
+		}
+	}
+
+	for _, m := range subtreeMetrics {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s gauge\n", m.name, m.help, m.name)
+		for _, rootPid := range roots {
+			root := pt.tree.Process(rootPid)
+			var total float64
+			for _, pid := range append([]int{rootPid}, pt.tree.Descendants(rootPid)...) {
+				if v, ok := m.value(pt.tree.Process(pid)); ok {
+					total += v
+				}
+			}
+			fmt.Fprintf(bw, "%s{root_pid=\"%d\",root_user=\"%s\",root_command=\"%s\"} %s\n",
+				m.name, root.PID, labelEscaper.Replace(root.User),
+				labelEscaper.Replace(commandName(root.Command)), formatMetricValue(total))
+		}
+	}
+
+	return bw.Flush()
+}
+
+// formatMetricValue formats a sample value in the shortest exact form
+func formatMetricValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
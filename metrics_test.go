@@ -0,0 +1,111 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+// fakePlatform returns a fixed process list
+type fakePlatform struct {
+	processes []tree.Process
+}
+
+func (f *fakePlatform) GetProcesses() ([]tree.Process, error) {
+	// Return a copy, since Proktree keeps pointers into the slice
+	return append([]tree.Process(nil), f.processes...), nil
+}
+
+func metricsTestProcesses() []tree.Process {
+	started := time.Unix(1750000000, 0)
+	return []tree.Process{
+		{PID: 1, PPID: 0, User: "root", CPUPct: 0.1, RSSKB: 1000, Command: "/sbin/init"},
+		{PID: 10, PPID: 1, User: "celery", CPUPct: 1.5, MemPct: 2, RSSKB: 2000, StartTime: &started, CPUTime: 10 * time.Second, Command: "celery -A proj worker"},
+		{PID: 11, PPID: 10, User: "celery", CPUPct: 20, MemPct: 3, RSSKB: 3000, CPUTime: 50 * time.Second, Command: "celery -A proj worker"},
+		{PID: 12, PPID: 10, User: "celery", CPUPct: 30.5, MemPct: 4, RSSKB: 4000, CPUTime: 60 * time.Second, Command: "/usr/bin/python3 helper.py"},
+		{PID: 20, PPID: 1, User: "bob", RSSKB: 500, Command: "vim \"notes\""},
+	}
+}
+
+func TestWriteMetrics(t *testing.T) {
+	handler := metricsHandler(CLI{SearchStrings: []string{"celery"}}, &fakePlatform{processes: metricsTestProcesses()})
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	if recorder.Code != 200 {
+		t.Fatalf("status = %d, want 200", recorder.Code)
+	}
+	if ct := recorder.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	output := recorder.Body.String()
+	for _, expected := range []string{
+		"# TYPE proktree_process_cpu_percent gauge",
+		`proktree_process_cpu_percent{pid="12",ppid="10",user="celery",command="python3"} 30.5`,
+		`proktree_process_resident_memory_bytes{pid="11",ppid="10",user="celery",command="celery"} 3072000`,
+		`proktree_process_start_time_seconds{pid="10",ppid="1",user="celery",command="celery"} 1750000000`,
+		// Only the outermost match is aggregated, and it includes every descendant
+		`proktree_subtree_processes{root_pid="10",root_user="celery",root_command="celery"} 3`,
+		`proktree_subtree_cpu_percent{root_pid="10",root_user="celery",root_command="celery"} 52`,
+		`proktree_subtree_resident_memory_bytes{root_pid="10",root_user="celery",root_command="celery"} 9216000`,
+		`proktree_subtree_cpu_seconds{root_pid="10",root_user="celery",root_command="celery"} 120`,
+	} {
+		if !strings.Contains(output, expected+"\n") {
+			t.Errorf("metrics missing %q", expected)
+		}
+	}
+
+	for _, unexpected := range []string{
+		`pid="20"`,  // Filtered out
+		`{pid="1",`, // Ancestor of a match, shown only for context
+		`proktree_process_start_time_seconds{pid="11"`, // Unknown start time
+		`root_pid="11"`, // Nested match
+	} {
+		if strings.Contains(output, unexpected) {
+			t.Errorf("metrics unexpectedly contain %q", unexpected)
+		}
+	}
+
+	if t.Failed() {
+		t.Logf("Got:\n%s", output)
+	}
+}
+
+func TestWriteMetricsNoFilters(t *testing.T) {
+	pt, err := snapshot(CLI{}, &fakePlatform{processes: metricsTestProcesses()})
+	if err != nil {
+		t.Fatalf("snapshot() error: %v", err)
+	}
+
+	var buf strings.Builder
+	if err := pt.writeMetrics(&buf); err != nil {
+		t.Fatalf("writeMetrics() error: %v", err)
+	}
+	output := buf.String()
+
+	// Label values are escaped
+	if !strings.Contains(output, `command="vim"`) {
+		t.Errorf("expected vim process in metrics")
+	}
+
+	// Without filters, every process has series
+	if !strings.Contains(output, `proktree_process_cpu_percent{pid="1",ppid="0",user="root",command="init"} 0.1`+"\n") {
+		t.Errorf("expected init process in metrics")
+	}
+
+	// Without filters, subtrees are aggregated from the roots
+	if !strings.Contains(output, `proktree_subtree_processes{root_pid="1",root_user="root",root_command="init"} 5`+"\n") {
+		t.Errorf("expected root subtree total, got:\n%s", output)
+	}
+}
+
+func TestLabelEscaper(t *testing.T) {
+	if got := labelEscaper.Replace("a\\b\"c\nd"); got != `a\\b\"c\nd` {
+		t.Errorf("labelEscaper = %q", got)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"time"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+// subreapers are commands known to adopt the orphaned descendants of their
+// children, as init does for everyone else
+var subreapers = map[string]bool{
+	"systemd":                 true, // systemd --user, as PID 1 is init anyway
+	"tini":                    true,
+	"dumb-init":               true,
+	"catatonit":               true,
+	"conmon":                  true,
+	"containerd-shim":         true,
+	"containerd-shim-runc-v2": true,
+}
+
+// orphanDelay is how much later than its reaper a child must start to be a
+// likely orphan, rather than something the reaper started while booting
+const orphanDelay = time.Minute
+
+// likelyOrphans returns the PIDs of processes that were likely adopted by
+// init or a subreaper after their parent exited. Those are children of a
+// reaper that don't lead their own session, as services and login sessions
+// do, and either whose session leader is gone or that started well after the
+// reaper. Processes whose session is unknown are judged by start time alone.
+func likelyOrphans(processes []tree.Process) map[int]bool {
+	byPID := make(map[int]*tree.Process, len(processes))
+	for i := range processes {
+		byPID[processes[i].PID] = &processes[i]
+	}
+
+	orphans := make(map[int]bool)
+	for i := range processes {
+		p := &processes[i]
+		reaper := byPID[p.PPID]
+		if reaper == nil || !isReaper(reaper) || p.Leader() {
+			continue
+		}
+
+		leaderGone := p.Session != nil && p.Session.SID != 0 && byPID[p.Session.SID] == nil
+		startedLater := p.StartTime != nil && reaper.StartTime != nil &&
+			p.StartTime.Sub(*reaper.StartTime) > orphanDelay
+		if leaderGone || startedLater {
+			orphans[p.PID] = true
+		}
+	}
+	return orphans
+}
+
+// isReaper reports whether p adopts orphans: init, or a known subreaper
+func isReaper(p *tree.Process) bool {
+	return p.PID == 1 || subreapers[commandName(p.Command)]
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+// orphanTestProcesses are services and a login session started at boot, a
+// worker left behind by a double-forking daemon, a job left by a closed
+// terminal, and a container
+func orphanTestProcesses() []tree.Process {
+	boot := time.Date(2025, 7, 1, 8, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time { t := boot.Add(d); return &t }
+	s := func(sid, pgid int) *tree.Session { return &tree.Session{SID: sid, PGID: pgid} }
+	return []tree.Process{
+		{PID: 1, StartTime: at(0), Command: "/sbin/init", Session: s(1, 1)},
+		{PID: 300, PPID: 1, StartTime: at(2 * time.Second), Command: "/usr/sbin/cron -f", Session: s(300, 300)},
+		{PID: 310, PPID: 1, StartTime: at(3 * time.Second), Command: "/usr/sbin/rsyslogd", Session: s(1, 310)}, // Boot, not a leader
+		{PID: 400, PPID: 1, StartTime: at(2 * time.Hour), Command: "nginx: master process", Session: s(400, 400)},
+		{PID: 1200, PPID: 1, StartTime: at(5 * time.Hour), Command: "celery worker", Session: s(1190, 1200)}, // Leader gone
+		{PID: 1201, PPID: 1200, StartTime: at(5 * time.Hour), Command: "celery worker", Session: s(1190, 1200)},
+		{PID: 1500, PPID: 1, StartTime: at(6 * time.Hour), Command: "systemd --user", Session: s(1500, 1500)},
+		{PID: 1600, PPID: 1500, StartTime: at(6*time.Hour + time.Minute), Command: "/usr/bin/pipewire", Session: s(1600, 1600)},
+		{PID: 1700, PPID: 1500, StartTime: at(7 * time.Hour), Command: "python3 notebook.py", Session: s(1650, 1700)}, // Terminal closed
+		{PID: 2000, PPID: 1, StartTime: at(3 * time.Hour), Command: "long-job"},                                       // Session unknown
+		{PID: 2100, PPID: 1, StartTime: at(30 * time.Second), Command: "early-job"},
+		{PID: 3000, PPID: 1, StartTime: at(4 * time.Hour), Command: "containerd-shim-runc-v2 -id abc", Session: s(3000, 3000)},
+		{PID: 3001, PPID: 3000, StartTime: at(4 * time.Hour), Command: "postgres", Session: s(3000, 3001)},
+	}
+}
+
+func TestLikelyOrphans(t *testing.T) {
+	var got []int
+	for pid := range likelyOrphans(orphanTestProcesses()) {
+		got = append(got, pid)
+	}
+	sort.Ints(got)
+	if expected := []int{1200, 1700, 2000}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("likelyOrphans() = %v, want %v", got, expected)
+	}
+}
+
+func TestOrphans(t *testing.T) {
+	tests := []struct {
+		name     string
+		cli      CLI
+		expected []string
+	}{
+		{
+			name: "only orphans",
+			cli:  CLI{Orphans: true},
+			expected: []string{
+				"1 ─┬─ /sbin/init",
+				"1200  ├─┬─ [orphan] celery worker",
+				"1201  │ └─── celery worker",
+				"1500  ├─┬─ systemd --user",
+				"1700  │ └─── [orphan] python3 notebook.py",
+				"2000  └─── [orphan] long-job",
+			},
+		},
+		{
+			name: "marked",
+			cli:  CLI{MarkOrphans: true, SearchStrings: []string{"systemd"}},
+			expected: []string{
+				"1 ─┬─ /sbin/init",
+				"1500  └─┬─ systemd --user",
+				"1600    ├─── /usr/bin/pipewire",
+				"1700    └─── [orphan] python3 notebook.py",
+			},
+		},
+		{
+			name: "unmarked",
+			cli:  CLI{SearchStrings: []string{"notebook"}},
+			expected: []string{
+				"1 ─┬─ /sbin/init",
+				"1500  └─┬─ systemd --user",
+				"1700    └─── python3 notebook.py",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pt := newTestProktree(t, tt.cli, orphanTestProcesses())
+			if err := pt.parseLineTemplate(`{{.PID}} {{.Tree}}{{if .Orphan}}[orphan] {{end}}{{.Command}}`); err != nil {
+				t.Fatalf("parseLineTemplate() error: %v", err)
+			}
+
+			var buf strings.Builder
+			if err := pt.printTrees(&buf); err != nil {
+				t.Fatalf("printTrees() error: %v", err)
+			}
+
+			lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+			if len(lines) != len(tt.expected) {
+				t.Fatalf("Expected %d lines, got %d:\n%s", len(tt.expected), len(lines), buf.String())
+			}
+			for i, expected := range tt.expected {
+				if lines[i] != expected {
+					t.Errorf("Line %d mismatch:\ngot:      %q\nexpected: %q", i, lines[i], expected)
+				}
+			}
+		})
+	}
+}
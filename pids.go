@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// selectedPIDs returns the sorted PIDs of the processes matching the filters,
+// with their ancestors and descendants if requested, or every PID if unfiltered
+func (pt *Proktree) selectedPIDs() []int {
+	if !pt.tree.Filtered() {
+		return pt.tree.PIDs()
+	}
+
+	selected := make(map[int]bool)
+	for _, pid := range pt.tree.PIDs() {
+		if !pt.tree.Matched(pid) {
+			continue
+		}
+		selected[pid] = true
+		if pt.cli.Ancestors {
+			for _, ancestor := range pt.tree.Ancestors(pid) {
+				selected[ancestor] = true
+			}
+		}
+		if pt.cli.Descendants {
+			for _, descendant := range pt.tree.Descendants(pid) {
+				selected[descendant] = true
+			}
+		}
+	}
+
+	pids := make([]int, 0, len(selected))
+	for pid := range selected {
+		pids = append(pids, pid)
+	}
+	sort.Ints(pids)
+	return pids
+}
+
+// anyMatched reports whether any process matched the filters; always true if
+// unfiltered and there are processes
+func (pt *Proktree) anyMatched() bool {
+	for _, pid := range pt.tree.PIDs() {
+		if !pt.tree.Filtered() || pt.tree.Matched(pid) {
+			return true
+		}
+	}
+	return false
+}
+
+// printPIDs writes the selected PIDs, one per line
+func (pt *Proktree) printPIDs(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, pid := range pt.selectedPIDs() {
+		fmt.Fprintln(bw, pid)
+	}
+	return bw.Flush()
+}
+
+// printCount writes the number of selected PIDs
+func (pt *Proktree) printCount(w io.Writer) error {
+	_, err := fmt.Fprintln(w, len(pt.selectedPIDs()))
+	return err
+}
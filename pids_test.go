@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrintPIDs(t *testing.T) {
+	tests := []struct {
+		name       string
+		cli        CLI
+		pids       string
+		count      string
+		anyMatched bool
+	}{
+		{
+			name:       "matches only",
+			cli:        CLI{SearchStrings: []string{"cc"}},
+			pids:       "11\n12\n13\n",
+			count:      "3\n",
+			anyMatched: true,
+		},
+		{
+			name:       "with ancestors",
+			cli:        CLI{PIDs: []string{"12"}, Ancestors: true},
+			pids:       "1\n10\n11\n12\n",
+			count:      "4\n",
+			anyMatched: true,
+		},
+		{
+			name:       "with descendants",
+			cli:        CLI{PIDs: []string{"10"}, Descendants: true},
+			pids:       "10\n11\n12\n13\n",
+			count:      "4\n",
+			anyMatched: true,
+		},
+		{
+			name:       "with ancestors and descendants",
+			cli:        CLI{PIDs: []string{"11"}, Ancestors: true, Descendants: true},
+			pids:       "1\n10\n11\n12\n",
+			count:      "4\n",
+			anyMatched: true,
+		},
+		{
+			name:       "no match",
+			cli:        CLI{Users: []string{"nobody"}, Ancestors: true},
+			pids:       "",
+			count:      "0\n",
+			anyMatched: false,
+		},
+		{
+			name:       "unfiltered",
+			cli:        CLI{},
+			pids:       "1\n10\n11\n12\n13\n20\n",
+			count:      "6\n",
+			anyMatched: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pt := newTestProktree(t, tt.cli, killTestProcesses())
+
+			var pids, count strings.Builder
+			if err := pt.printPIDs(&pids); err != nil {
+				t.Fatalf("printPIDs() error: %v", err)
+			}
+			if err := pt.printCount(&count); err != nil {
+				t.Fatalf("printCount() error: %v", err)
+			}
+
+			if pids.String() != tt.pids {
+				t.Errorf("printPIDs() = %q, want %q", pids.String(), tt.pids)
+			}
+			if count.String() != tt.count {
+				t.Errorf("printCount() = %q, want %q", count.String(), tt.count)
+			}
+			if pt.anyMatched() != tt.anyMatched {
+				t.Errorf("anyMatched() = %v, want %v", pt.anyMatched(), tt.anyMatched)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestSetPriorityLinux(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start sleep: %v", err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+	pid := cmd.Process.Pid
+
+	// Lowering priority is always allowed for our own processes
+	if err := setNice(pid, 15); err != nil {
+		t.Fatalf("setNice() error: %v", err)
+	}
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		t.Skipf("cannot read stat: %v", err)
+	}
+	// Fields after the parenthesized command; nice is field 19 overall
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	if nice := fields[16]; nice != "15" {
+		t.Errorf("nice = %s, want 15", nice)
+	}
+
+	if err := setIOPriority(pid, ioClasses["idle"], 0); err != nil {
+		t.Fatalf("setIOPriority() error: %v", err)
+	}
+	prio, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_GET, ioprioWhoProcess, uintptr(pid), 0)
+	if errno != 0 {
+		t.Fatalf("ioprio_get error: %v", errno)
+	}
+	if class := int(prio) >> ioprioClassShift; class != ioClasses["idle"] {
+		t.Errorf("I/O class = %d, want %d", class, ioClasses["idle"])
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package main
+
+import "errors"
+
+// setNice is unsupported outside Unix
+func setNice(pid, nice int) error {
+	return errors.New("renice is unsupported on this platform")
+}
+
+// setIOPriority is only supported on Linux
+func setIOPriority(pid, class, level int) error {
+	return errors.New("ionice is only supported on Linux")
+}
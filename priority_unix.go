@@ -0,0 +1,18 @@
+//go:build unix && !linux
+
+package main
+
+import (
+	"errors"
+	"syscall"
+)
+
+// setNice sets the nice value of pid
+func setNice(pid, nice int) error {
+	return syscall.Setpriority(syscall.PRIO_PROCESS, pid, nice)
+}
+
+// setIOPriority is only supported on Linux
+func setIOPriority(pid, class, level int) error {
+	return errors.New("ionice is only supported on Linux")
+}
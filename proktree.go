@@ -1,16 +1,18 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/user"
-	"sort"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/alecthomas/kong"
+	"github.com/jeremywohl/proktree/tree"
 	"golang.org/x/term"
 )
 
@@ -18,433 +20,433 @@ import (
 const Version = "1.2.0"
 
 // DefaultScreenWidth is the fallback when terminal width cannot be determined
-const DefaultScreenWidth = 80
+const DefaultScreenWidth = tree.DefaultScreenWidth
 
 // Command-line args
 type CLI struct {
-	PIDs              []string `short:"p" name:"pid" help:"Show only parents and descendants of process PID (can be specified multiple times)"`
-	Users             []string `short:"u" name:"user" help:"Show only parents and descendants of processes of USER (can be specified multiple times)"`
-	CurrentUser       bool     `name:"me" help:"Show only parents and descendants of processes of current user"`
-	CurrentUserAlt    bool     `name:"mine" help:"Show only parents and descendants of processes of current user (alias for --me)"`
-	SearchStrings     []string `short:"s" name:"string" help:"Show only parents and descendants of process names containing STRING (can be specified multiple times)"`
-	SearchStringsCase []string `short:"i" name:"string-insensitive" help:"Show only parents and descendants of process names containing STRING case-insensitively (can be specified multiple times)"`
-	ShowFullUser      bool     `name:"long-users" help:"Show full usernames, without truncation"`
-	ShowFullCommand   bool     `name:"long-commands" help:"Show full commands, without truncation"`
-	Indent            int      `name:"indent" help:"Number of spaces for each indentation level (default: 2)" default:"2"`
-	Version           bool     `short:"v" name:"version" help:"Show version and exit"`
+	PIDs              []string      `short:"p" name:"pid" help:"Show only parents and descendants of process PID (can be specified multiple times)"`
+	Users             []string      `short:"u" name:"user" help:"Show only parents and descendants of processes of USER (can be specified multiple times)"`
+	CurrentUser       bool          `name:"me" help:"Show only parents and descendants of processes of current user"`
+	CurrentUserAlt    bool          `name:"mine" help:"Show only parents and descendants of processes of current user (alias for --me)"`
+	SearchStrings     []string      `short:"s" name:"string" help:"Show only parents and descendants of process names containing STRING (can be specified multiple times)"`
+	SearchStringsCase []string      `short:"i" name:"string-insensitive" help:"Show only parents and descendants of process names containing STRING case-insensitively (can be specified multiple times)"`
+	Ports             []int         `name:"port" help:"Show only parents and descendants of processes with a TCP or UDP socket on local PORT, Linux only (can be specified multiple times)"`
+	Env               []string      `name:"env" help:"Show only parents and descendants of processes with environment variable KEY, or KEY=VALUE, Linux only (can be specified multiple times)" placeholder:"KEY[=VALUE]"`
+	TTYs              []string      `name:"tty" help:"Show only parents and descendants of processes with controlling terminal TTY, e.g. pts/3 (can be specified multiple times)"`
+	Orphans           bool          `name:"orphans" help:"Show only parents and descendants of likely orphans: processes adopted by init or a subreaper after their parent exited"`
+	Files             []string      `name:"file" help:"Show only parents and descendants of processes with FILE open, even if deleted, Linux only (can be specified multiple times)"`
+	ShowFullUser      bool          `name:"long-users" help:"Show full usernames, without truncation"`
+	ShowFullCommand   bool          `name:"long-commands" help:"Show full commands, without truncation"`
+	BySession         bool          `name:"by-session" help:"Arrange the tree by session: session leaders at the top, with the processes of their session under them"`
+	MarkOrphans       bool          `name:"mark-orphans" help:"Mark likely orphans with [orphan] in the tree"`
+	ShowFDs           bool          `name:"show-fds" help:"Show the open files of each process as leaves under it, Linux only"`
+	Sample            time.Duration `name:"sample" help:"Show CPU usage, and I/O rates, measured over DURATION, e.g. 1s, instead of averaged over each process's life" placeholder:"DURATION"`
+	Indent            int           `name:"indent" help:"Number of spaces for each indentation level (default: 2)" default:"2"`
+	Columns           []string      `name:"columns" help:"Extra columns to show, comma-separated: ni (nice value), sid (session ID), pgid (process group ID), tty (controlling terminal), subcpu (%CPU of the process and its descendants), pss (proportional memory), uss (unique memory), swap (swapped memory), subpss (PSS of the process and its descendants), read and write (storage I/O, per second with --sample), subread and subwrite (I/O of the process and its descendants), listen (listening ports), fds (open descriptors), exe (executable), cwd (working directory), root (root directory); memory, I/O, listen, fds, cwd and root are Linux only" enum:"ni,sid,pgid,tty,subcpu,pss,uss,swap,subpss,read,write,subread,subwrite,listen,fds,exe,cwd,root"`
+	ShowEnv           []string      `name:"show-env" help:"Show environment variables as extra columns, comma-separated, Linux only" placeholder:"KEY"`
+	Glyphs            string        `name:"glyphs" help:"Tree graphics: unicode, ascii, rounded or heavy (default: unicode)" enum:"unicode,ascii,rounded,heavy" default:"unicode"`
+	Color             string        `name:"color" help:"Color output: auto, always or never (default: auto, off if NO_COLOR is set)" enum:"auto,always,never" default:"auto"`
+	Theme             string        `name:"theme" help:"Color theme: default, light or minimal (default: default)" enum:"default,light,minimal" default:"default"`
+	Colors            string        `name:"colors" help:"Override theme colors, e.g. 'matched=1;33:glyphs=2:user.root=31' (default: $PROKTREE_COLORS)"`
+	Format            string        `name:"format" help:"Format each line with a Go text/template, e.g. '{{.PID}} {{.User}} {{.Tree}}{{.Command}}'"`
+	Output            string        `name:"output" help:"Output format: tree, html, csv, tsv or folded (default: tree)" enum:"tree,html,csv,tsv,folded" default:"tree"`
+	Weight            string        `name:"weight" help:"Weight for folded output: rss, cpu, time, read or write (default: rss)" enum:"rss,cpu,time,read,write" default:"rss"`
+	PIDsOnly          bool          `name:"pids-only" help:"Print only the PIDs of matching processes, one per line" xor:"mode"`
+	Count             bool          `name:"count" help:"Print only the number of matching processes" xor:"mode"`
+	Quiet             bool          `short:"q" name:"quiet" help:"Print nothing; the exit status tells whether any process matched" xor:"mode"`
+	Ancestors         bool          `name:"ancestors" help:"With --pids-only or --count, include the ancestors of matching processes"`
+	Descendants       bool          `name:"descendants" help:"With --pids-only or --count, include the descendants of matching processes"`
+	Config            string        `name:"config" help:"Read defaults from this config file (default: $XDG_CONFIG_HOME/proktree/config.toml)" placeholder:"PATH"`
+	Profile           string        `name:"profile" help:"Use the defaults of a [profiles.NAME] table of the config file" placeholder:"NAME"`
+	Version           bool          `short:"v" name:"version" help:"Show version and exit"`
+
+	Tree   struct{}  `cmd:"" default:"1" hidden:"" help:"Print the process tree (the default)"`
+	Serve  ServeCmd  `cmd:"" help:"Serve Prometheus metrics and a JSON API for processes matching the filters"`
+	Kill   KillCmd   `cmd:"" help:"Signal processes matching the filters and all their descendants, leaves first"`
+	Wait   WaitCmd   `cmd:"" help:"Wait until processes matching the filters and all their descendants have exited"`
+	Stop   struct{}  `cmd:"" help:"Pause processes matching the filters and all their descendants with SIGSTOP, parents first"`
+	Cont   struct{}  `cmd:"" help:"Resume processes matching the filters and all their descendants with SIGCONT, leaves first"`
+	Renice ReniceCmd `cmd:"" help:"Change the priority of processes matching the filters and all their descendants"`
 }
 
 // Main comms
 type Proktree struct {
-	processes     map[int]*Process
-	children      map[int][]int
-	skipPids      map[int]bool
-	pidsToShow    map[int]bool
-	rootPids      []int
-	maxUserLen    int
-	maxStartLen   int
-	maxTimeLen    int
-	termWidth     int
-	headerPrinted bool
-	cli           CLI
-	nowFunc       func() time.Time // For testing; defaults to time.Now
+	tree      *tree.Tree // All processes, filtered by the CLI filters
+	renderer  *tree.Renderer
+	cli       CLI
+	orphans   map[int]bool // Likely orphans, by PID, with --orphans or --mark-orphans
+	ancestors map[int]bool // Ancestors of proktree, like the shell it runs in, by PID
 }
 
-func main() {
+// glyphSets are the tree graphics available with --glyphs, by name
+var glyphSets = map[string]*tree.Glyphs{
+	"unicode": &tree.UnicodeGlyphs,
+	"ascii":   &tree.ASCIIGlyphs,
+	"rounded": &tree.RoundedGlyphs,
+	"heavy":   &tree.HeavyGlyphs,
+}
+
+// newProktree returns a Proktree for the given command-line args, with no processes loaded
+func newProktree(cli CLI) *Proktree {
+	// Invalid colors are reported by main, before any Proktree is made
+	theme, _ := colorTheme(cli)
+
 	pt := &Proktree{
-		cli:       CLI{},
-		processes: make(map[int]*Process),
-		children:  make(map[int][]int),
-		skipPids:  make(map[int]bool),
-		termWidth: getTerminalWidth(),
-		nowFunc:   time.Now,
+		cli: cli,
+		renderer: &tree.Renderer{
+			Indent:      cli.Indent,
+			FullUser:    cli.ShowFullUser,
+			FullCommand: cli.ShowFullCommand,
+			Width:       getTerminalWidth(),
+			Now:         time.Now,
+			Theme:       theme,
+			Glyphs:      glyphSets[cli.Glyphs],
+			Files:       cli.ShowFDs,
+		},
+	}
+	pt.renderer.Columns = append(pt.extraColumns(cli.Columns), envColumns(cli.ShowEnv)...)
+	if cli.Orphans || cli.MarkOrphans {
+		pt.renderer.Tag = func(p *tree.Process) string {
+			if pt.orphans[p.PID] {
+				return "[orphan]"
+			}
+			return ""
+		}
+	}
+	return pt
+}
+
+// Exit statuses, as with pgrep
+const (
+	exitNoMatch = 1 // The filters matched no process, or wait timed out
+	exitUsage   = 2 // Invalid options
+	exitFailure = 3 // proktree itself failed, e.g. listing processes
+)
+
+// errNoMatch is returned by commands whose filters matched no process
+var errNoMatch = errors.New("no matching processes")
+
+// usageError is a problem with the options given, rather than one met while running
+type usageError struct{ error }
+
+// usagef returns a usageError formatted as by fmt.Errorf
+func usagef(format string, args ...any) error {
+	return usageError{fmt.Errorf(format, args...)}
+}
+
+// exitStatus returns the exit status for an error
+func exitStatus(err error) int {
+	var usage usageError
+	switch {
+	case errors.Is(err, errNoMatch) || errors.Is(err, errTimedOut):
+		return exitNoMatch
+	case errors.As(err, &usage):
+		return exitUsage
 	}
+	return exitFailure
+}
+
+// fail reports an error on stderr, returning the exit status for it
+func fail(stderr io.Writer, err error, format string, args ...any) int {
+	fmt.Fprintf(stderr, format+"\n", args...)
+	return exitStatus(err)
+}
+
+func main() {
+	var cli CLI
 
 	// Parse command-line arguments
-	_ = kong.Parse(&pt.cli,
+	ctx := kong.Parse(&cli,
 		kong.Name("proktree"),
 		kong.Description("Print your processes as a tree, nicely displayed"),
 		kong.UsageOnError(),
 		kong.ConfigureHelp(kong.HelpOptions{
 			Compact: false,
 		}),
+		kong.Exit(func(code int) {
+			// Invalid options, including those of the config file
+			if code != 0 {
+				code = exitUsage
+			}
+			os.Exit(code)
+		}),
 	)
 
+	os.Exit(run(cli, ctx.Command(), tree.GetPlatform(), os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command with the parsed CLI, returning the exit status
+func run(cli CLI, command string, native tree.Platform, stdin io.Reader, stdout, stderr io.Writer) int {
 	// Handle version flag
-	if pt.cli.Version {
-		fmt.Printf("proktree version %s\n", Version)
-		os.Exit(0)
+	if cli.Version {
+		fmt.Fprintf(stdout, "proktree version %s\n", Version)
+		return 0
 	}
 
 	// If --me or --mine was used, add current user
-	if pt.cli.CurrentUser || pt.cli.CurrentUserAlt {
+	if cli.CurrentUser || cli.CurrentUserAlt {
 		if currentUser, err := user.Current(); err == nil {
-			pt.cli.Users = append(pt.cli.Users, currentUser.Username)
+			cli.Users = append(cli.Users, currentUser.Username)
 		}
 	}
 
-	// Get all processes
-	platform := GetPlatform()
-	processList, err := platform.GetProcesses()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed to get processes: %v\n", err)
-		os.Exit(1)
+	if cli.Sample < 0 {
+		err := usagef("invalid sample: %v (must be positive)", cli.Sample)
+		return fail(stderr, err, "%v", err)
 	}
 
-	pt.buildProcessRelationships(processList)
-	pt.applyFilters()
-	pt.calculateColumnWidths()
-	pt.printTrees(os.Stdout)
-}
+	if _, err := colorTheme(cli); err != nil {
+		return fail(stderr, usageError{err}, "invalid colors: %v", err)
+	}
 
-// now returns the current time using nowFunc if set, otherwise time.Now
-func (pt *Proktree) now() time.Time {
-	if pt.nowFunc != nil {
-		return pt.nowFunc()
+	platform := newPlatform(cli, native, stderr)
+
+	// Run subcommands other than the default tree display
+	var err error
+	switch command {
+	case "serve":
+		err = runServe(cli, platform)
+	case "kill":
+		err = runKill(cli, platform, stdin, stdout)
+	case "wait":
+		f, ok := stdout.(*os.File)
+		redraw := ok && term.IsTerminal(int(f.Fd()))
+		err = runWait(cli, platform, stdout, redraw)
+	case "stop":
+		err = runStop(cli, platform, stdout)
+	case "cont":
+		err = runCont(cli, platform, stdout)
+	case "renice":
+		err = runRenice(cli, platform, stdout)
+	default:
+		return printTree(cli, platform, stdout, stderr)
 	}
-	return time.Now()
+	if err != nil {
+		return fail(stderr, err, "%s: %v", command, err)
+	}
+	return 0
 }
 
-// buildProcessRelationships builds parent-child relationships
-func (pt *Proktree) buildProcessRelationships(processList []Process) {
-	proktreePid := -1
-	for i := range processList {
-		p := &processList[i]
-		pt.processes[p.PID] = p
-		if p.PPID > 0 {
-			pt.children[p.PPID] = append(pt.children[p.PPID], p.PID)
-		}
+// printTree prints the processes matching the filters in the chosen output
+// mode, returning the exit status
+func printTree(cli CLI, platform tree.Platform, stdout, stderr io.Writer) int {
+	pt := newProktree(cli)
 
-		// Mark proktree for skipping
-		if strings.Contains(p.Command, "proktree") {
-			proktreePid = p.PID
-			pt.skipPids[p.PID] = true
+	// Parse a custom line format
+	if pt.cli.Format != "" {
+		if err := pt.parseLineTemplate(pt.cli.Format); err != nil {
+			return fail(stderr, usageError{err}, "invalid format: %v", err)
 		}
 	}
 
-	// Store for ps children skipping
-	if proktreePid > 0 {
-		for _, childPid := range pt.children[proktreePid] {
-			if p, ok := pt.processes[childPid]; ok && strings.HasPrefix(p.Command, "ps ") {
-				pt.skipPids[childPid] = true
-			}
-		}
+	// Get all processes
+	processList, err := platform.GetProcesses()
+	if err != nil {
+		return fail(stderr, err, "failed to get processes: %v", err)
 	}
-}
-
-// applyFilters applies CLI filters to determine which processes to show
-func (pt *Proktree) applyFilters() {
-	pt.rootPids, pt.pidsToShow = pt.filterProcesses()
-	sort.Ints(pt.rootPids)
-}
 
-// calculateColumnWidths calculates the maximum width for variable columns
-func (pt *Proktree) calculateColumnWidths() {
-	pt.maxUserLen = 10
-	if pt.cli.ShowFullUser {
-		// Find actual max user length when showing full names
-		for _, p := range pt.processes {
-			if len(p.User) > pt.maxUserLen {
-				pt.maxUserLen = len(p.User)
-			}
-		}
+	if err := pt.load(processList); err != nil {
+		return fail(stderr, err, "%v", err)
 	}
-	pt.maxStartLen = 5
-	pt.maxTimeLen = 4
 
-	for _, p := range pt.processes {
-		startStr := pt.formatStartTime(p.StartTime)
-		if len(startStr) > pt.maxStartLen {
-			pt.maxStartLen = len(startStr)
+	switch {
+	case pt.cli.Quiet:
+	case pt.cli.PIDsOnly:
+		if err := pt.printPIDs(stdout); err != nil {
+			return fail(stderr, err, "failed to write pids: %v", err)
 		}
-		timeStr := formatCPUTime(p.CPUTime)
-		if len(strings.TrimSpace(timeStr)) > pt.maxTimeLen {
-			pt.maxTimeLen = len(strings.TrimSpace(timeStr))
+	case pt.cli.Count:
+		if err := pt.printCount(stdout); err != nil {
+			return fail(stderr, err, "failed to write count: %v", err)
+		}
+	case pt.cli.Output == "html":
+		if err := pt.printHTML(stdout); err != nil {
+			return fail(stderr, err, "failed to write html: %v", err)
+		}
+	case pt.cli.Output == "csv" || pt.cli.Output == "tsv":
+		comma := ','
+		if pt.cli.Output == "tsv" {
+			comma = '\t'
+		}
+		if err := pt.printDelimited(stdout, comma); err != nil {
+			return fail(stderr, err, "failed to write %s: %v", pt.cli.Output, err)
+		}
+	case pt.cli.Output == "folded":
+		if err := pt.printFolded(stdout, pt.cli.Weight); err != nil {
+			return fail(stderr, err, "failed to write folded stacks: %v", err)
+		}
+	default:
+		if err := pt.printTrees(stdout); err != nil {
+			return fail(stderr, err, "failed to write tree: %v", err)
 		}
 	}
 
-	// Ensure minimum width for TIME column
-	if pt.maxTimeLen < 8 {
-		pt.maxTimeLen = 8
+	// Like pgrep, exit 1 if the filters matched nothing
+	if !pt.anyMatched() {
+		return exitNoMatch
 	}
+	return 0
 }
 
-// printHeader prints the column headers
-func (pt *Proktree) printHeader(w io.Writer) {
-	header := fmt.Sprintf("  %5s %-*s %5s %5s %5s   %-*s  %-*s  %s",
-		centerText("PID", 5), pt.maxUserLen, centerText("USER", pt.maxUserLen), "%CPU", "%MEM", "RSS",
-		pt.maxStartLen, "START",
-		pt.maxTimeLen, centerText("TIME", pt.maxTimeLen),
-		"COMMAND")
-	fmt.Fprintln(w, header)
-	if pt.cli.ShowFullCommand {
-		// When showing full commands, use a fixed width separator
-		fmt.Fprintln(w, strings.Repeat("-", DefaultScreenWidth))
-	} else if pt.termWidth > 0 {
-		fmt.Fprintln(w, strings.Repeat("-", pt.termWidth))
-	} else {
-		// When piped without terminal, use a fixed width separator
-		fmt.Fprintln(w, strings.Repeat("-", DefaultScreenWidth))
+// now returns the current time using the renderer's clock
+func (pt *Proktree) now() time.Time {
+	if pt.renderer.Now != nil {
+		return pt.renderer.Now()
 	}
+	return time.Now()
 }
 
-// printTrees prints all process trees
-func (pt *Proktree) printTrees(w io.Writer) {
-	for i, rootPid := range pt.rootPids {
-		isLast := i == len(pt.rootPids)-1
-		pt.printProcessTree(w, rootPid, isLast)
+// load builds the process tree, without proktree itself, and applies the CLI filters
+func (pt *Proktree) load(processList []tree.Process) error {
+	filter, err := pt.filter()
+	if err != nil {
+		return err
 	}
-}
-
-// processLine represents a buffered output line with tree metadata
-type processLine struct {
-	pid                int
-	depth              int
-	isLast             bool
-	hasVisibleChildren bool
-	content            string // The formatted process info without tree graphics
-	prefixParts        []bool // Array of bools for each indent level: true = has vertical line, false = spaces
-}
-
-// collectProcessLines collects all process lines that should be displayed
-func (pt *Proktree) collectProcessLines(pid int, depth int, prefixParts []bool, isLast bool) []processLine {
-
-	if pt.skipPids[pid] {
-		return nil
+	pt.ancestors = selfAncestors(processList)
+	processList = withoutSelf(processList)
+	// Orphans are found by their real parents, before any rearranging
+	if pt.cli.Orphans || pt.cli.MarkOrphans {
+		pt.orphans = likelyOrphans(processList)
 	}
-
-	p, ok := pt.processes[pid]
-	if !ok {
-		return nil
+	if pt.cli.BySession {
+		processList = bySession(processList)
 	}
+	pt.tree = tree.New(processList).Filter(filter)
+	return nil
+}
 
-	// Check if we should display this process
-	shouldDisplay := pt.pidsToShow == nil || pt.pidsToShow[pid]
-	if !shouldDisplay {
-		// Still need to collect children
-		var lines []processLine
-		childPids := pt.children[pid]
-		sort.Ints(childPids)
-
-		visibleChildren := 0
-		for _, childPid := range childPids {
-			if !pt.skipPids[childPid] && (pt.pidsToShow == nil || pt.pidsToShow[childPid]) {
-				visibleChildren++
-			}
-		}
-
-		childIdx := 0
-		for _, childPid := range childPids {
-			if pt.skipPids[childPid] {
-				continue
-			}
-
-			// Determine child prefix parts based on whether THIS process is last
-			var childPrefixParts []bool
-			if depth == 0 {
-				// Root's children start with no prefix
-				childPrefixParts = []bool{}
-			} else {
-				// Copy parent's prefix and add new level
-				childPrefixParts = make([]bool, len(prefixParts)+1)
-				copy(childPrefixParts, prefixParts)
-				// If this process is last, children get spaces (false)
-				// If this process is not last, children get a vertical line (true)
-				childPrefixParts[len(prefixParts)] = !isLast
-			}
-
-			if pt.pidsToShow != nil && !pt.pidsToShow[childPid] {
-				// Need to check if this child has visible descendants
-				childLines := pt.collectProcessLines(childPid, depth+1, childPrefixParts, false)
-				if len(childLines) > 0 {
-					lines = append(lines, childLines...)
-				}
-				continue
-			}
+// filter returns the process filter given by the CLI
+func (pt *Proktree) filter() (tree.Filter, error) {
+	filter := tree.Filter{
+		Users:              pt.cli.Users,
+		Strings:            pt.cli.SearchStrings,
+		StringsInsensitive: pt.cli.SearchStringsCase,
+		Ports:              pt.cli.Ports,
+	}
 
-			childIdx++
-			isLastChild := childIdx == visibleChildren
-			childLines := pt.collectProcessLines(childPid, depth+1, childPrefixParts, isLastChild)
-			lines = append(lines, childLines...)
+	// Open files are known by absolute path, with symlinks resolved
+	for _, path := range pt.cli.Files {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return filter, usagef("invalid file: %s", path)
 		}
-		return lines
-	}
-
-	// Format the process info
-	content := fmt.Sprintf("%7d %-*s %5.1f %5.1f %6s  %-*s  %-*s",
-		p.PID, pt.maxUserLen, pt.truncateUser(p.User),
-		p.CPUPct, p.MemPct, formatRSS(p.RSSKB),
-		pt.maxStartLen, pt.formatStartTime(p.StartTime),
-		pt.maxTimeLen, formatCPUTime(p.CPUTime))
-
-	// Check if has visible children
-	childPids := pt.children[pid]
-	hasVisibleChildren := false
-	for _, childPid := range childPids {
-		if !pt.skipPids[childPid] && (pt.pidsToShow == nil || pt.pidsToShow[childPid]) {
-			hasVisibleChildren = true
-			break
+		if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+			abs = resolved
 		}
+		filter.Files = append(filter.Files, abs)
 	}
 
-	line := processLine{
-		pid:                pid,
-		depth:              depth,
-		prefixParts:        prefixParts,
-		isLast:             isLast,
-		hasVisibleChildren: hasVisibleChildren,
-		content:            content,
+	if pt.cli.Orphans {
+		filter.Func = func(p *tree.Process) bool { return pt.orphans[p.PID] }
 	}
 
-	lines := []processLine{line}
-
-	// Collect children
-	sort.Ints(childPids)
-
-	visibleChildren := 0
-	for _, childPid := range childPids {
-		if !pt.skipPids[childPid] && (pt.pidsToShow == nil || pt.pidsToShow[childPid]) {
-			visibleChildren++
-		}
+	// Terminals are known by their name under /dev
+	for _, tty := range pt.cli.TTYs {
+		filter.TTYs = append(filter.TTYs, strings.TrimPrefix(tty, "/dev/"))
 	}
 
-	childIdx := 0
-	for _, childPid := range childPids {
-		if pt.skipPids[childPid] {
-			continue
+	for _, env := range pt.cli.Env {
+		if key, _, _ := strings.Cut(env, "="); key == "" {
+			return filter, usagef("invalid env: %s (want KEY or KEY=VALUE)", env)
 		}
+		filter.Env = append(filter.Env, env)
+	}
 
-		// Determine child prefix parts based on whether THIS process is last
-		var childPrefixParts []bool
-		if depth == 0 {
-			// Root's children start with no prefix
-			childPrefixParts = []bool{}
-		} else {
-			// Copy parent's prefix and add new level
-			childPrefixParts = make([]bool, len(prefixParts)+1)
-			copy(childPrefixParts, prefixParts)
-			// If this process is last, children get spaces (false)
-			// If this process is not last, children get a vertical line (true)
-			childPrefixParts[len(prefixParts)] = !isLast
+	for _, pidStr := range pt.cli.PIDs {
+		pid, err := strconv.Atoi(pidStr)
+		if err != nil {
+			return filter, usagef("invalid pid: %s", pidStr)
 		}
-
-		if pt.pidsToShow != nil && !pt.pidsToShow[childPid] {
-			// Need to check if this child has visible descendants
-			childLines := pt.collectProcessLines(childPid, depth+1, childPrefixParts, false)
-			if len(childLines) > 0 {
-				lines = append(lines, childLines...)
-			}
-			continue
-		}
-
-		childIdx++
-		isLastChild := childIdx == visibleChildren
-		childLines := pt.collectProcessLines(childPid, depth+1, childPrefixParts, isLastChild)
-		lines = append(lines, childLines...)
+		filter.PIDs = append(filter.PIDs, pid)
 	}
 
-	return lines
+	return filter, nil
 }
 
-// renderProcessTree renders the collected lines with optimized tree graphics
-func (pt *Proktree) renderProcessTree(w io.Writer, lines []processLine) {
-	// If there are lines to render and the header hasn't been printed yet, print it now
-	if len(lines) > 0 && !pt.headerPrinted {
-		pt.printHeader(w)
-		pt.headerPrinted = true
-	}
-
-	// Build indentation strings based on the configured indent size
-	indentSpace := strings.Repeat(" ", pt.cli.Indent)
-	indentVertical := "│" + strings.Repeat(" ", pt.cli.Indent-1)
-
-	// Render each line
-	for _, line := range lines {
-		// Build the prefix string from the prefix parts
-		var prefix strings.Builder
-		for _, hasVertical := range line.prefixParts {
-			if hasVertical {
-				prefix.WriteString(indentVertical)
-			} else {
-				prefix.WriteString(indentSpace)
-			}
-		}
+// withoutSelf drops proktree itself, and the ps processes it spawns, from a process list
+func withoutSelf(processList []tree.Process) []tree.Process {
+	skipPids := make(map[int]bool)
 
-		// Determine the branch characters
-		var branch string
+	// Mark proktree for skipping
+	proktreePid := -1
+	for _, p := range processList {
+		if strings.Contains(p.Command, "proktree") {
+			proktreePid = p.PID
+			skipPids[p.PID] = true
+		}
+	}
 
-		if line.depth == 0 {
-			if line.hasVisibleChildren {
-				branch = "─┬" + strings.Repeat("─", pt.cli.Indent-1)
-			} else {
-				branch = strings.Repeat("─", pt.cli.Indent+1)
-			}
-		} else if line.isLast {
-			if line.hasVisibleChildren {
-				branch = "└" + strings.Repeat("─", pt.cli.Indent-1) + "┬" + strings.Repeat("─", pt.cli.Indent-1)
-			} else {
-				branch = "└" + strings.Repeat("─", pt.cli.Indent*2-1)
-			}
-		} else {
-			if line.hasVisibleChildren {
-				branch = "├" + strings.Repeat("─", pt.cli.Indent-1) + "┬" + strings.Repeat("─", pt.cli.Indent-1)
-			} else {
-				branch = "├" + strings.Repeat("─", pt.cli.Indent*2-1)
+	// Mark its ps children for skipping
+	if proktreePid > 0 {
+		for _, p := range processList {
+			if p.PPID == proktreePid && strings.HasPrefix(p.Command, "ps ") {
+				skipPids[p.PID] = true
 			}
 		}
+	}
 
-		// Get the command
-		p := pt.processes[line.pid]
+	if len(skipPids) == 0 {
+		return processList
+	}
 
-		// Build the full line with proper tree alignment
-		treeStr := prefix.String() + branch
-		// Root needs 2 spaces, others need 3 for proper alignment
-		spacing := "   "
-		if line.depth == 0 {
-			spacing = "  "
-		}
-		fullLine := fmt.Sprintf("%s%s%s %s", line.content, spacing, treeStr, p.Command)
+	// Skipped processes take their descendants with them
+	all := tree.New(processList)
+	var descendants []int
+	for pid := range skipPids {
+		descendants = append(descendants, all.Descendants(pid)...)
+	}
+	for _, pid := range descendants {
+		skipPids[pid] = true
+	}
 
-		// Truncate if too long
-		if !pt.cli.ShowFullCommand && pt.termWidth > 0 && len(fullLine) > pt.termWidth && pt.termWidth > 3 {
-			runes := []rune(fullLine)
-			if len(runes) > pt.termWidth-3 {
-				fullLine = string(runes[:pt.termWidth-3]) + "..."
-			}
+	var kept []tree.Process
+	for _, p := range processList {
+		if !skipPids[p.PID] {
+			kept = append(kept, p)
 		}
-
-		fmt.Fprintln(w, fullLine)
 	}
+	return kept
 }
 
-// printProcessTree prints a process tree starting from the given PID
-func (pt *Proktree) printProcessTree(w io.Writer, pid int, isLast bool) {
-	// Collect all lines
-	lines := pt.collectProcessLines(pid, 0, []bool{}, isLast)
+// parentPID returns the PID of proktree's parent; tests replace it
+var parentPID = os.Getppid
 
-	// Render with optimized tree graphics
-	pt.renderProcessTree(w, lines)
-}
+// selfAncestors returns the ancestors of proktree in a process list, starting
+// with its parent, by PID
+func selfAncestors(processList []tree.Process) map[int]bool {
+	ppids := make(map[int]int, len(processList))
+	for _, p := range processList {
+		ppids[p.PID] = p.PPID
+	}
 
-func centerText(text string, width int) string {
-	padding := width - len(text)
-	if padding <= 0 {
-		return text
+	ancestors := make(map[int]bool)
+	for pid := parentPID(); pid > 0 && !ancestors[pid]; pid = ppids[pid] {
+		if _, ok := ppids[pid]; !ok {
+			break
+		}
+		ancestors[pid] = true
 	}
-	leftPad := padding / 2
-	rightPad := padding - leftPad
-	return strings.Repeat(" ", leftPad) + text + strings.Repeat(" ", rightPad)
+	return ancestors
 }
 
-// truncateUser truncates usernames based on CLI settings
-func (pt *Proktree) truncateUser(user string) string {
-	if pt.cli.ShowFullUser {
-		return user
+// printTrees prints all process trees
+func (pt *Proktree) printTrees(w io.Writer) error {
+	return pt.renderer.Render(w, pt.tree)
+}
+
+// commandName returns the base name of a command's executable, e.g. "sshd" for "/usr/sbin/sshd -D"
+func commandName(command string) string {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return "?"
 	}
-	if len(user) <= 10 {
-		return user
+
+	// Login shells are "-bash", and many daemons retitle themselves "name: detail"
+	name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(fields[0]), "-"), ":")
+	if name == "" {
+		return "?"
 	}
-	return user[:7] + "..."
+	return name
 }
 
 func getTerminalWidth() int {
@@ -482,164 +484,3 @@ func getTerminalWidth() int {
 
 	return termWidth
 }
-
-// formatRSS formats RSS in KB to a human-readable string
-func formatRSS(rssKB float64) string {
-	if rssKB >= 1048576 {
-		return fmt.Sprintf("%.1fG", rssKB/1048576)
-	}
-	return fmt.Sprintf("%.1fM", rssKB/1024)
-}
-
-// formatStartTime formats start time for display
-func (pt *Proktree) formatStartTime(startTime *time.Time) string {
-	if startTime == nil {
-		return "--"
-	}
-
-	now := pt.now()
-	ageHours := now.Sub(*startTime).Hours()
-
-	if ageHours < 24 {
-		// Less than 24 hours ago: show HH:MM
-		return startTime.Format("15:04")
-	} else if startTime.Year() == now.Year() {
-		// Current year: show MonDD
-		return startTime.Format("Jan02")
-	} else {
-		// Previous years: show YYYY
-		return startTime.Format("2006")
-	}
-}
-
-// formatCPUTime formats CPU time duration for display
-func formatCPUTime(cpuTime time.Duration) string {
-	if cpuTime == 0 {
-		return "      --"
-	}
-
-	totalSeconds := int(cpuTime.Seconds())
-	hours := totalSeconds / 3600
-	minutes := (totalSeconds % 3600) / 60
-	seconds := totalSeconds % 60
-
-	if hours >= 24 {
-		// Right-justify the hours format
-		return fmt.Sprintf("%5dhrs", hours)
-	} else {
-		// HH:MM:SS format for under 24 hours
-		return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
-	}
-}
-
-// filterProcesses applies CLI filters and returns root PIDs and PIDs to show
-func (pt *Proktree) filterProcesses() ([]int, map[int]bool) {
-	hasFilters := len(pt.cli.PIDs) > 0 || len(pt.cli.Users) > 0 || len(pt.cli.SearchStrings) > 0 || len(pt.cli.SearchStringsCase) > 0
-	var rootPids []int
-	var pidsToShow map[int]bool
-
-	if hasFilters {
-		matchingPids := pt.findMatchingPids()
-		pidsToShow = pt.expandToAncestorsAndDescendants(matchingPids)
-
-		// Always start from true root processes (ppid = 0)
-		// This ensures we get proper tree structure
-		for pid, p := range pt.processes {
-			if p.PPID == 0 && pidsToShow[pid] {
-				rootPids = append(rootPids, pid)
-			}
-		}
-	} else {
-		// No filters, show all from root
-		// Find all root processes (those with ppid 0 or no parent in our set)
-		for pid, p := range pt.processes {
-			if p.PPID == 0 || pt.processes[p.PPID] == nil {
-				rootPids = append(rootPids, pid)
-			}
-		}
-	}
-
-	return rootPids, pidsToShow
-}
-
-// findMatchingPids finds PIDs that match the given filters
-func (pt *Proktree) findMatchingPids() map[int]bool {
-	matchingPids := make(map[int]bool)
-
-	for _, p := range pt.processes {
-		if pt.skipPids[p.PID] {
-			continue
-		}
-
-		// Check PID filters
-		for _, pidStr := range pt.cli.PIDs {
-			if strconv.Itoa(p.PID) == pidStr {
-				matchingPids[p.PID] = true
-			}
-		}
-
-		// Check user filters
-		for _, user := range pt.cli.Users {
-			if p.User == user {
-				matchingPids[p.PID] = true
-			}
-		}
-
-		// Check string filters
-		for _, str := range pt.cli.SearchStrings {
-			if strings.Contains(p.Command, str) {
-				matchingPids[p.PID] = true
-			}
-		}
-
-		// Check case-insensitive string filters
-		for _, str := range pt.cli.SearchStringsCase {
-			if strings.Contains(strings.ToLower(p.Command), strings.ToLower(str)) {
-				matchingPids[p.PID] = true
-			}
-		}
-	}
-
-	return matchingPids
-}
-
-// expandToAncestorsAndDescendants expands matching PIDs to include all ancestors and descendants
-func (pt *Proktree) expandToAncestorsAndDescendants(matchingPids map[int]bool) map[int]bool {
-	pidsToShow := make(map[int]bool)
-
-	for pid := range matchingPids {
-		// Add the matching PID itself
-		pidsToShow[pid] = true
-
-		// Add all ancestors
-		current := pid
-		for {
-			if p, ok := pt.processes[current]; ok && p.PPID > 0 {
-				pidsToShow[p.PPID] = true
-				current = p.PPID
-			} else {
-				break
-			}
-		}
-
-		// Add all descendants
-		queue := []int{pid}
-		visited := make(map[int]bool)
-		visited[pid] = true
-
-		for len(queue) > 0 {
-			current := queue[0]
-			queue = queue[1:]
-
-			for _, child := range pt.children[current] {
-				if !visited[child] {
-					pidsToShow[child] = true
-					queue = append(queue, child)
-					visited[child] = true
-				}
-			}
-		}
-	}
-
-	return pidsToShow
-}
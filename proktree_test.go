@@ -1,196 +1,26 @@
 package main
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
-	"time"
-)
-
-func TestFormatStartTime(t *testing.T) {
-	// Use a fixed time for testing
-	fixedNow := time.Date(2025, 7, 16, 10, 0, 0, 0, time.UTC)
-
-	// Create a Proktree instance with fixed nowFunc
-	pt := &Proktree{
-		nowFunc: func() time.Time { return fixedNow },
-	}
-
-	tests := []struct {
-		name     string
-		input    *time.Time
-		expected string
-	}{
-		{
-			name:     "nil input",
-			input:    nil,
-			expected: "--",
-		},
-		{
-			name:     "recent time (less than 24 hours)",
-			input:    func() *time.Time { t := fixedNow.Add(-2 * time.Hour); return &t }(),
-			expected: fixedNow.Add(-2 * time.Hour).Format("15:04"),
-		},
-		{
-			name:     "current year",
-			input:    func() *time.Time { t := fixedNow.Add(-48 * time.Hour); return &t }(),
-			expected: fixedNow.Add(-48 * time.Hour).Format("Jan02"),
-		},
-		{
-			name:     "previous year",
-			input:    func() *time.Time { t := fixedNow.Add(-400 * 24 * time.Hour); return &t }(),
-			expected: fixedNow.Add(-400 * 24 * time.Hour).Format("2006"),
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := pt.formatStartTime(tt.input)
-			if result != tt.expected {
-				t.Errorf("formatStartTime(%v) = %q, want %q", tt.input, result, tt.expected)
-			}
-		})
-	}
-}
-
-func TestFormatCPUTime(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    time.Duration
-		expected string
-	}{
-		{
-			name:     "zero duration",
-			input:    0,
-			expected: "      --",
-		},
-		{
-			name:     "minutes and seconds",
-			input:    1*time.Minute + 23*time.Second + 450*time.Millisecond,
-			expected: "00:01:23",
-		},
-		{
-			name:     "hours and minutes",
-			input:    12*time.Hour + 34*time.Minute + 56*time.Second,
-			expected: "12:34:56",
-		},
-		{
-			name:     "24+ hours",
-			input:    25 * time.Hour,
-			expected: "   25hrs",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := formatCPUTime(tt.input)
-			if result != tt.expected {
-				t.Errorf("formatCPUTime(%v) = %q, want %q", tt.input, result, tt.expected)
-			}
-		})
-	}
-}
-
-func TestCenterText(t *testing.T) {
-	tests := []struct {
-		name     string
-		text     string
-		width    int
-		expected string
-	}{
-		{
-			name:     "text shorter than width",
-			text:     "PID",
-			width:    5,
-			expected: " PID ",
-		},
-		{
-			name:     "text equal to width",
-			text:     "HELLO",
-			width:    5,
-			expected: "HELLO",
-		},
-		{
-			name:     "text longer than width",
-			text:     "TOOLONG",
-			width:    5,
-			expected: "TOOLONG",
-		},
-		{
-			name:     "even padding",
-			text:     "HI",
-			width:    6,
-			expected: "  HI  ",
-		},
-		{
-			name:     "odd padding",
-			text:     "HI",
-			width:    5,
-			expected: " HI  ",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := centerText(tt.text, tt.width)
-			if result != tt.expected {
-				t.Errorf("centerText(%q, %d) = %q, want %q", tt.text, tt.width, result, tt.expected)
-			}
-		})
-	}
-}
 
-func TestTruncateUser(t *testing.T) {
-
-	tests := []struct {
-		name         string
-		user         string
-		showFullUser bool
-		expected     string
-	}{
-		{
-			name:         "short username",
-			user:         "root",
-			showFullUser: false,
-			expected:     "root",
-		},
-		{
-			name:         "long username truncated",
-			user:         "verylongusername",
-			showFullUser: false,
-			expected:     "verylon...",
-		},
-		{
-			name:         "long username full",
-			user:         "verylongusername",
-			showFullUser: true,
-			expected:     "verylongusername",
-		},
-		{
-			name:         "exactly 10 chars",
-			user:         "1234567890",
-			showFullUser: false,
-			expected:     "1234567890",
-		},
-		{
-			name:         "11 chars truncated",
-			user:         "12345678901",
-			showFullUser: false,
-			expected:     "1234567...",
-		},
-	}
+	"github.com/jeremywohl/proktree/tree"
+)
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			pt := &Proktree{
-				cli: CLI{ShowFullUser: tt.showFullUser},
-			}
-			result := pt.truncateUser(tt.user)
-			if result != tt.expected {
-				t.Errorf("truncateUser(%q) = %q, want %q", tt.user, result, tt.expected)
-			}
-		})
+// newTestProktree returns a Proktree loaded with processes, without truncation or color
+func newTestProktree(t *testing.T, cli CLI, processes []tree.Process) *Proktree {
+	t.Helper()
+	pt := newProktree(cli)
+	pt.renderer.Width = 0
+	pt.renderer.Theme = nil
+	if err := pt.load(processes); err != nil {
+		t.Fatalf("load() error: %v", err)
 	}
+	return pt
 }
 
 func TestGetTerminalWidth(t *testing.T) {
@@ -245,743 +75,128 @@ func TestGetTerminalWidth(t *testing.T) {
 	}
 }
 
-func TestProcessFiltering(t *testing.T) {
-	processes := map[int]*Process{
-		1: {PID: 1, PPID: 0, User: "root", Command: "init"},
-		2: {PID: 2, PPID: 1, User: "root", Command: "kernel_task"},
-		3: {PID: 3, PPID: 1, User: "daemon", Command: "systemd"},
-		4: {PID: 4, PPID: 3, User: "daemon", Command: "cron"},
-		5: {PID: 5, PPID: 3, User: "user1", Command: "bash"},
-		6: {PID: 6, PPID: 5, User: "user1", Command: "vim test.txt"},
-	}
-
-	pidToChildren := map[int][]int{
-		1: {2, 3},
-		3: {4, 5},
-		5: {6},
-	}
-
-	tests := []struct {
-		name             string
-		cli              CLI
-		expectedPidsShow map[int]bool
-		expectedRootPids []int
-	}{
-		{
-			name: "filter by PID",
-			cli: CLI{
-				PIDs: []string{"5"},
-			},
-			expectedPidsShow: map[int]bool{
-				1: true, // ancestor
-				3: true, // ancestor
-				5: true, // matched
-				6: true, // descendant
-			},
-			expectedRootPids: []int{1},
-		},
-		{
-			name: "filter by user",
-			cli: CLI{
-				Users: []string{"daemon"},
-			},
-			expectedPidsShow: map[int]bool{
-				1: true, // ancestor
-				3: true, // matched
-				4: true, // matched (child of 3, also daemon)
-				5: true, // descendant of matched
-				6: true, // descendant of matched
-			},
-			expectedRootPids: []int{1},
-		},
-		{
-			name: "filter by string",
-			cli: CLI{
-				SearchStrings: []string{"vim"},
-			},
-			expectedPidsShow: map[int]bool{
-				1: true, // ancestor
-				3: true, // ancestor
-				5: true, // ancestor
-				6: true, // matched
-			},
-			expectedRootPids: []int{1},
-		},
-		{
-			name: "filter by case-insensitive string",
-			cli: CLI{
-				SearchStringsCase: []string{"VIM"},
-			},
-			expectedPidsShow: map[int]bool{
-				1: true, // ancestor
-				3: true, // ancestor
-				5: true, // ancestor
-				6: true, // matched
-			},
-			expectedRootPids: []int{1},
-		},
-		{
-			name: "multiple filters",
-			cli: CLI{
-				Users:         []string{"user1"},
-				SearchStrings: []string{"bash"},
-			},
-			expectedPidsShow: map[int]bool{
-				1: true, // ancestor
-				3: true, // ancestor
-				5: true, // matched both
-				6: true, // descendant
-			},
-			expectedRootPids: []int{1},
-		},
-		{
-			name:             "no filters",
-			cli:              CLI{},
-			expectedPidsShow: nil,      // No filtering, so pidsToShow should be nil
-			expectedRootPids: []int{1}, // Root process
-		},
+func TestWithoutSelf(t *testing.T) {
+	processes := []tree.Process{
+		{PID: 1, PPID: 0, Command: "init"},
+		{PID: 10, PPID: 1, Command: "bash"},
+		{PID: 20, PPID: 10, Command: "proktree -s nginx"},
+		{PID: 21, PPID: 20, Command: "ps -eo pid,ppid"},
+		{PID: 30, PPID: 10, Command: "tmux new -s proktree"},
+		{PID: 31, PPID: 30, Command: "vim"},
+		{PID: 40, PPID: 10, Command: "ps aux"},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			skipPids := make(map[int]bool)
-			pt := &Proktree{
-				processes: processes,
-				children:  pidToChildren,
-				skipPids:  skipPids,
-				cli:       tt.cli,
-			}
-			rootPids, pidsToShow := pt.filterProcesses()
-
-			// Check root PIDs
-			if !equalIntSlices(rootPids, tt.expectedRootPids) {
-				t.Errorf("rootPids = %v, want %v", rootPids, tt.expectedRootPids)
-			}
-
-			// Check PIDs to show
-			if tt.expectedPidsShow == nil {
-				if pidsToShow != nil {
-					t.Errorf("pidsToShow = %v, want nil", pidsToShow)
-				}
-			} else {
-				for pid, expected := range tt.expectedPidsShow {
-					if pidsToShow[pid] != expected {
-						t.Errorf("PID %d: got %v, want %v", pid, pidsToShow[pid], expected)
-					}
-				}
-				// Also check that no unexpected PIDs are included
-				for pid, included := range pidsToShow {
-					if included && !tt.expectedPidsShow[pid] {
-						t.Errorf("PID %d included but not expected", pid)
-					}
-				}
-			}
-		})
+	var pids []int
+	for _, p := range withoutSelf(processes) {
+		pids = append(pids, p.PID)
 	}
-}
 
-// Helper function to compare int slices
-func equalIntSlices(a, b []int) bool {
-	if len(a) != len(b) {
-		return false
+	// proktree, its ps child, and anything else mentioning proktree go, with their descendants
+	expected := []int{1, 10, 40}
+	if len(pids) != len(expected) {
+		t.Fatalf("withoutSelf() kept %v, want %v", pids, expected)
 	}
-	for i := range a {
-		if a[i] != b[i] {
-			return false
+	for i := range expected {
+		if pids[i] != expected[i] {
+			t.Errorf("withoutSelf() kept %v, want %v", pids, expected)
+			break
 		}
 	}
-	return true
-}
-
-func TestProcessTreeOutput(t *testing.T) {
-	// Use a fixed "now" time for consistent test results
-	testNow := time.Date(2025, 7, 16, 10, 0, 0, 0, time.UTC)
 
-	// Create test processes with static times relative to testNow
-	jul10 := time.Date(2025, 7, 10, 0, 0, 0, 0, time.UTC)     // Current year -> "Jul10"
-	jun01 := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)      // Current year -> "Jun01"
-	recent1 := time.Date(2025, 7, 15, 13, 10, 0, 0, time.UTC) // Within 24h of testNow -> "13:10"
-	recent2 := time.Date(2025, 7, 15, 14, 40, 0, 0, time.UTC) // Within 24h of testNow -> "14:40"
-	lastYear := time.Date(2023, 12, 25, 0, 0, 0, 0, time.UTC) // Previous year -> "2023"
-
-	processes := map[int]*Process{
-		1: {
-			PID:       1,
-			PPID:      0,
-			User:      "root",
-			CPUPct:    1.5,
-			MemPct:    0.8,
-			RSSKB:     31641.6, // 30.9M
-			StartTime: &jul10,
-			CPUTime:   28*time.Minute + 35*time.Second,
-			Command:   "/sbin/launchd",
-		},
-		100: {
-			PID:       100,
-			PPID:      1,
-			User:      "daemon",
-			CPUPct:    0.0,
-			MemPct:    0.1,
-			RSSKB:     10240.0, // 10.0M
-			StartTime: &jul10,
-			CPUTime:   0,
-			Command:   "/usr/sbin/sshd",
-		},
-		200: {
-			PID:       200,
-			PPID:      100,
-			User:      "alice",
-			CPUPct:    25.3,
-			MemPct:    15.2,
-			RSSKB:     1048576.0, // 1.0G
-			StartTime: nil,
-			CPUTime:   25 * time.Hour,
-			Command:   "sshd: alice [priv]",
-		},
-		201: {
-			PID:       201,
-			PPID:      200,
-			User:      "alice",
-			CPUPct:    0.1,
-			MemPct:    0.5,
-			RSSKB:     5120.0, // 5.0M
-			StartTime: &recent1,
-			CPUTime:   2*time.Minute + 15*time.Second,
-			Command:   "-bash",
-		},
-		300: {
-			PID:       300,
-			PPID:      1,
-			User:      "postgres",
-			CPUPct:    5.2,
-			MemPct:    12.3,
-			RSSKB:     524288.0, // 512M
-			StartTime: &jun01,
-			CPUTime:   125 * time.Hour,
-			Command:   "/usr/bin/postgres -D /var/lib/postgresql",
-		},
-		301: {
-			PID:       301,
-			PPID:      300,
-			User:      "postgres",
-			CPUPct:    0.5,
-			MemPct:    2.1,
-			RSSKB:     102400.0, // 100M
-			StartTime: &jun01,
-			CPUTime:   45*time.Minute + 30*time.Second,
-			Command:   "postgres: writer process",
-		},
-		302: {
-			PID:       302,
-			PPID:      300,
-			User:      "postgres",
-			CPUPct:    0.3,
-			MemPct:    1.8,
-			RSSKB:     81920.0, // 80M
-			StartTime: &jun01,
-			CPUTime:   22 * time.Minute,
-			Command:   "postgres: checkpointer",
-		},
-		400: {
-			PID:       400,
-			PPID:      1,
-			User:      "bob",
-			CPUPct:    15.7,
-			MemPct:    8.9,
-			RSSKB:     204800.0, // 200M
-			StartTime: &recent2,
-			CPUTime:   5*time.Minute + 45*time.Second,
-			Command:   "node server.js",
-		},
-		500: {
-			PID:       500,
-			PPID:      1,
-			User:      "verylongusername",
-			CPUPct:    0.0,
-			MemPct:    0.1,
-			RSSKB:     2048.0, // 2.0M
-			StartTime: &lastYear,
-			CPUTime:   0,
-			Command:   "/usr/local/bin/custom-daemon",
-		},
+	// Skipping every process prints nothing, not even the header
+	pt := newTestProktree(t, CLI{Indent: 2}, []tree.Process{
+		{PID: 1, PPID: 0, Command: "proktree"},
+		{PID: 2, PPID: 1, Command: "ps -eo pid"},
+	})
+	var buf strings.Builder
+	if err := pt.printTrees(&buf); err != nil {
+		t.Fatalf("printTrees() error: %v", err)
 	}
-
-	pidToChildren := map[int][]int{
-		1:   {100, 300, 400, 500},
-		100: {200},
-		200: {201},
-		300: {301, 302},
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output, got %q", buf.String())
 	}
+}
 
-	skipPids := make(map[int]bool)
-
-	tests := []struct {
-		name         string
-		cli          CLI
-		maxUserLen   int
-		showFullUser bool
-		expected     []string // Expected output lines
-	}{
-		{
-			name:       "no filter - show all",
-			cli:        CLI{},
-			maxUserLen: 10,
-			expected: []string{
-				"   PID     USER     %CPU  %MEM   RSS   START    TIME    COMMAND",
-				"--------------------------------------------------------------------------------",
-				"      1 root         1.5   0.8  30.9M  Jul10  00:28:35  ─┬─ /sbin/launchd",
-				"    100 daemon       0.0   0.1  10.0M  Jul10        --   ├─┬─ /usr/sbin/sshd",
-				"    200 alice       25.3  15.2   1.0G  --        25hrs   │ └─┬─ sshd: alice [priv]",
-				"    201 alice        0.1   0.5   5.0M  13:10  00:02:15   │   └─── -bash",
-				"    300 postgres     5.2  12.3 512.0M  Jun01    125hrs   ├─┬─ /usr/bin/postgres -D /var/lib/postgresql",
-				"    301 postgres     0.5   2.1 100.0M  Jun01  00:45:30   │ ├─── postgres: writer process",
-				"    302 postgres     0.3   1.8  80.0M  Jun01  00:22:00   │ └─── postgres: checkpointer",
-				"    400 bob         15.7   8.9 200.0M  14:40  00:05:45   ├─── node server.js",
-				"    500 verylon...   0.0   0.1   2.0M  2023         --   └─── /usr/local/bin/custom-daemon",
-			},
-		},
-		{
-			name:       "filter by PID - shows ancestors and descendants",
-			cli:        CLI{PIDs: []string{"200"}},
-			maxUserLen: 10,
-			expected: []string{
-				"   PID     USER     %CPU  %MEM   RSS   START    TIME    COMMAND",
-				"--------------------------------------------------------------------------------",
-				"      1 root         1.5   0.8  30.9M  Jul10  00:28:35  ─┬─ /sbin/launchd",
-				"    100 daemon       0.0   0.1  10.0M  Jul10        --   └─┬─ /usr/sbin/sshd",
-				"    200 alice       25.3  15.2   1.0G  --        25hrs     └─┬─ sshd: alice [priv]",
-				"    201 alice        0.1   0.5   5.0M  13:10  00:02:15       └─── -bash",
-			},
-		},
-		{
-			name:       "filter by user alice",
-			cli:        CLI{Users: []string{"alice"}},
-			maxUserLen: 10,
-			expected: []string{
-				"   PID     USER     %CPU  %MEM   RSS   START    TIME    COMMAND",
-				"--------------------------------------------------------------------------------",
-				"      1 root         1.5   0.8  30.9M  Jul10  00:28:35  ─┬─ /sbin/launchd",
-				"    100 daemon       0.0   0.1  10.0M  Jul10        --   └─┬─ /usr/sbin/sshd",
-				"    200 alice       25.3  15.2   1.0G  --        25hrs     └─┬─ sshd: alice [priv]",
-				"    201 alice        0.1   0.5   5.0M  13:10  00:02:15       └─── -bash",
-			},
-		},
-		{
-			name:       "filter by user postgres",
-			cli:        CLI{Users: []string{"postgres"}},
-			maxUserLen: 10,
-			expected: []string{
-				"   PID     USER     %CPU  %MEM   RSS   START    TIME    COMMAND",
-				"--------------------------------------------------------------------------------",
-				"      1 root         1.5   0.8  30.9M  Jul10  00:28:35  ─┬─ /sbin/launchd",
-				"    300 postgres     5.2  12.3 512.0M  Jun01    125hrs   └─┬─ /usr/bin/postgres -D /var/lib/postgresql",
-				"    301 postgres     0.5   2.1 100.0M  Jun01  00:45:30     ├─── postgres: writer process",
-				"    302 postgres     0.3   1.8  80.0M  Jun01  00:22:00     └─── postgres: checkpointer",
-			},
-		},
-		{
-			name:       "filter by command postgres",
-			cli:        CLI{SearchStrings: []string{"postgres"}},
-			maxUserLen: 10,
-			expected: []string{
-				"   PID     USER     %CPU  %MEM   RSS   START    TIME    COMMAND",
-				"--------------------------------------------------------------------------------",
-				"      1 root         1.5   0.8  30.9M  Jul10  00:28:35  ─┬─ /sbin/launchd",
-				"    300 postgres     5.2  12.3 512.0M  Jun01    125hrs   └─┬─ /usr/bin/postgres -D /var/lib/postgresql",
-				"    301 postgres     0.5   2.1 100.0M  Jun01  00:45:30     ├─── postgres: writer process",
-				"    302 postgres     0.3   1.8  80.0M  Jun01  00:22:00     └─── postgres: checkpointer",
-			},
-		},
-		{
-			name:       "filter by multiple users",
-			cli:        CLI{Users: []string{"alice", "bob"}},
-			maxUserLen: 10,
-			expected: []string{
-				"   PID     USER     %CPU  %MEM   RSS   START    TIME    COMMAND",
-				"--------------------------------------------------------------------------------",
-				"      1 root         1.5   0.8  30.9M  Jul10  00:28:35  ─┬─ /sbin/launchd",
-				"    100 daemon       0.0   0.1  10.0M  Jul10        --   ├─┬─ /usr/sbin/sshd",
-				"    200 alice       25.3  15.2   1.0G  --        25hrs   │ └─┬─ sshd: alice [priv]",
-				"    201 alice        0.1   0.5   5.0M  13:10  00:02:15   │   └─── -bash",
-				"    400 bob         15.7   8.9 200.0M  14:40  00:05:45   └─── node server.js",
-			},
-		},
-		{
-			name:         "full username display",
-			cli:          CLI{Users: []string{"verylongusername"}},
-			maxUserLen:   16,
-			showFullUser: true,
-			expected: []string{
-				"   PID        USER        %CPU  %MEM   RSS   START    TIME    COMMAND",
-				"--------------------------------------------------------------------------------",
-				"      1 root               1.5   0.8  30.9M  Jul10  00:28:35  ─┬─ /sbin/launchd",
-				"    500 verylongusername   0.0   0.1   2.0M  2023         --   └─── /usr/local/bin/custom-daemon",
-			},
-		},
+func TestInvalidPIDFilter(t *testing.T) {
+	pt := newProktree(CLI{PIDs: []string{"12x"}})
+	if err := pt.load(nil); err == nil || err.Error() != "invalid pid: 12x" {
+		t.Errorf("load() error = %v, want invalid pid", err)
 	}
+}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-
-			// Create test CLI with showFullUser setting
-			testCLI := tt.cli
-			testCLI.ShowFullUser = tt.showFullUser
-			testCLI.Indent = 2
-
-			// Create a test Proktree instance
-			pt := &Proktree{
-				processes:   processes,
-				children:    pidToChildren,
-				skipPids:    skipPids,
-				maxStartLen: 5,
-				maxTimeLen:  8,
-				termWidth:   0,
-				cli:         testCLI,
-				nowFunc:     func() time.Time { return testNow },
-			}
-
-			// Calculate column widths properly
-			pt.calculateColumnWidths()
-
-			// Override if test specifies a specific maxUserLen
-			if tt.maxUserLen > 0 {
-				pt.maxUserLen = tt.maxUserLen
-			}
-
-			// Apply filters using the actual filtering logic
-			rootPids, pidsToShow := pt.filterProcesses()
-			pt.pidsToShow = pidsToShow
-
-			// Should have one root PID
-			if len(rootPids) != 1 || rootPids[0] != 1 {
-				t.Errorf("Expected root PID 1, got %v", rootPids)
-			}
-
-			// Capture output
-			var buf strings.Builder
-			pt.printProcessTree(&buf, 1, true)
-
-			// Get lines from output
-			output := strings.TrimRight(buf.String(), "\n")
-			var lines []string
-			if output != "" {
-				lines = strings.Split(output, "\n")
-			}
-
-			// Compare output
-			if len(lines) != len(tt.expected) {
-				t.Errorf("Expected %d lines, got %d", len(tt.expected), len(lines))
-				t.Logf("Got:\n%s", strings.Join(lines, "\n"))
-				return
-			}
-
-			for i, expected := range tt.expected {
-				if i >= len(lines) {
-					t.Errorf("Missing line %d: expected %q", i, expected)
-					continue
-				}
-				if lines[i] != expected {
-					t.Errorf("Line %d mismatch:\ngot:      %q\nexpected: %q", i, lines[i], expected)
-				}
-			}
-		})
+func TestInvalidEnvFilter(t *testing.T) {
+	pt := newProktree(CLI{Env: []string{"=1234"}})
+	if err := pt.load(nil); err == nil || err.Error() != "invalid env: =1234 (want KEY or KEY=VALUE)" {
+		t.Errorf("load() error = %v, want invalid env", err)
 	}
 }
 
-func TestNoHeaderWhenNoProcesses(t *testing.T) {
-	// Create test processes
-	processes := map[int]*Process{
-		1: {
-			PID:       1,
-			PPID:      0,
-			User:      "root",
-			CPUPct:    0.0,
-			MemPct:    0.0,
-			RSSKB:     1024.0,
-			StartTime: nil,
-			CPUTime:   0,
-			Command:   "init",
-		},
-		10: {
-			PID:       10,
-			PPID:      1,
-			User:      "user1",
-			CPUPct:    0.0,
-			MemPct:    0.0,
-			RSSKB:     1024.0,
-			StartTime: nil,
-			CPUTime:   0,
-			Command:   "process1",
-		},
-		20: {
-			PID:       20,
-			PPID:      1,
-			User:      "user2",
-			CPUPct:    0.0,
-			MemPct:    0.0,
-			RSSKB:     1024.0,
-			StartTime: nil,
-			CPUTime:   0,
-			Command:   "process2",
-		},
+func TestFileFilter(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
 	}
-
-	pidToChildren := map[int][]int{
-		1: {10, 20},
+	lock := filepath.Join(dir, "app.lock")
+	if err := os.WriteFile(lock, nil, 0o644); err != nil {
+		t.Fatal(err)
 	}
-
-	tests := []struct {
-		name           string
-		cli            CLI
-		expectedOutput string // Empty string means no output expected
-	}{
-		{
-			name: "filter with no matches - no header printed",
-			cli: CLI{
-				Users: []string{"nonexistentuser"},
-			},
-			expectedOutput: "",
-		},
-		{
-			name: "filter by non-existent PID - no header printed",
-			cli: CLI{
-				PIDs: []string{"9999"},
-			},
-			expectedOutput: "",
-		},
-		{
-			name: "filter by non-matching string - no header printed",
-			cli: CLI{
-				SearchStrings: []string{"nonexistentprocess"},
-			},
-			expectedOutput: "",
-		},
-		{
-			name:           "skip all processes - no header printed",
-			cli:            CLI{},
-			expectedOutput: "",
-		},
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(dir, link); err != nil {
+		t.Fatal(err)
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// For the "skip all processes" test, mark all PIDs as skipped
-			testSkipPids := make(map[int]bool)
-			if tt.name == "skip all processes - no header printed" {
-				for pid := range processes {
-					testSkipPids[pid] = true
-				}
-			}
-
-			// Create a test Proktree instance
-			testCLI := tt.cli
-			testCLI.Indent = 2 // Set default indentation
-			pt := &Proktree{
-				processes:     processes,
-				children:      pidToChildren,
-				skipPids:      testSkipPids,
-				maxUserLen:    10,
-				maxStartLen:   5,
-				maxTimeLen:    8,
-				termWidth:     80,
-				cli:           testCLI,
-				headerPrinted: false,
-			}
-
-			// Apply filters
-			pt.rootPids, pt.pidsToShow = pt.filterProcesses()
-			pt.calculateColumnWidths()
-
-			// Capture output
-			var buf strings.Builder
-			pt.printTrees(&buf)
-
-			// Get output
-			output := strings.TrimSpace(buf.String())
-
-			// Check if output matches expected
-			if output != tt.expectedOutput {
-				t.Errorf("Expected output %q, got %q", tt.expectedOutput, output)
-			}
-
-			// Verify header was not printed
-			if pt.headerPrinted {
-				t.Errorf("Header should not have been printed for test case: %s", tt.name)
-			}
-		})
+	// Paths are matched as the kernel reports them: absolute, without symlinks
+	pt := newTestProktree(t, CLI{Files: []string{filepath.Join(link, "app.lock"), filepath.Join(dir, "gone.log")}}, []tree.Process{
+		{PID: 1, PPID: 0, User: "root", Command: "init"},
+		{PID: 10, PPID: 1, User: "root", Command: "app", Files: []tree.File{{FD: 3, Path: lock}}},
+		{PID: 20, PPID: 1, User: "root", Command: "logger", Files: []tree.File{{FD: 1, Path: filepath.Join(dir, "gone.log") + " (deleted)"}}},
+		{PID: 30, PPID: 1, User: "root", Command: "idle", Files: []tree.File{}},
+	})
+	if got := pt.tree.PIDs(); !slices.Equal(got, []int{1, 10, 20}) {
+		t.Errorf("PIDs() = %v, want [1 10 20]", got)
 	}
 }
 
-func TestIndentation(t *testing.T) {
-	// Create simple test processes
-	processes := map[int]*Process{
-		1: {
-			PID:       1,
-			PPID:      0,
-			User:      "root",
-			CPUPct:    0.0,
-			MemPct:    0.0,
-			RSSKB:     1024.0,
-			StartTime: nil,
-			CPUTime:   0,
-			Command:   "init",
-		},
-		10: {
-			PID:       10,
-			PPID:      1,
-			User:      "user",
-			CPUPct:    0.0,
-			MemPct:    0.0,
-			RSSKB:     1024.0,
-			StartTime: nil,
-			CPUTime:   0,
-			Command:   "parent",
-		},
-		20: {
-			PID:       20,
-			PPID:      10,
-			User:      "user",
-			CPUPct:    0.0,
-			MemPct:    0.0,
-			RSSKB:     1024.0,
-			StartTime: nil,
-			CPUTime:   0,
-			Command:   "child1",
-		},
-		21: {
-			PID:       21,
-			PPID:      10,
-			User:      "user",
-			CPUPct:    0.0,
-			MemPct:    0.0,
-			RSSKB:     1024.0,
-			StartTime: nil,
-			CPUTime:   0,
-			Command:   "child2",
-		},
-		30: {
-			PID:       30,
-			PPID:      20,
-			User:      "user",
-			CPUPct:    0.0,
-			MemPct:    0.0,
-			RSSKB:     1024.0,
-			StartTime: nil,
-			CPUTime:   0,
-			Command:   "grandchild",
-		},
-	}
+// failingPlatform is a platform that can't list processes
+type failingPlatform struct{}
 
-	pidToChildren := map[int][]int{
-		1:  {10},
-		10: {20, 21},
-		20: {30},
-	}
+func (failingPlatform) GetProcesses() ([]tree.Process, error) {
+	return nil, errors.New("ps not found")
+}
 
-	skipPids := make(map[int]bool)
+func TestRunExitStatus(t *testing.T) {
+	processes := &sequencePlatform{lists: [][]tree.Process{killTestProcesses()}}
 
 	tests := []struct {
-		name         string
-		indentSize   int
-		expectedTree []string
+		name     string
+		cli      CLI
+		command  string
+		platform tree.Platform
+		expected int
+		stderr   string
 	}{
-		{
-			name:       "default indentation (2 spaces)",
-			indentSize: 2,
-			expectedTree: []string{
-				"   PID     USER     %CPU  %MEM   RSS   START    TIME    COMMAND",
-				"--------------------------------------------------------------------------------",
-				"      1 root         0.0   0.0   1.0M  --           --  ─┬─ init",
-				"     10 user         0.0   0.0   1.0M  --           --   └─┬─ parent",
-				"     20 user         0.0   0.0   1.0M  --           --     ├─┬─ child1",
-				"     30 user         0.0   0.0   1.0M  --           --     │ └─── grandchild",
-				"     21 user         0.0   0.0   1.0M  --           --     └─── child2",
-			},
-		},
-		{
-			name:       "single space indentation",
-			indentSize: 1,
-			expectedTree: []string{
-				"   PID     USER     %CPU  %MEM   RSS   START    TIME    COMMAND",
-				"--------------------------------------------------------------------------------",
-				"      1 root         0.0   0.0   1.0M  --           --  ─┬ init",
-				"     10 user         0.0   0.0   1.0M  --           --   └┬ parent",
-				"     20 user         0.0   0.0   1.0M  --           --    ├┬ child1",
-				"     30 user         0.0   0.0   1.0M  --           --    │└─ grandchild",
-				"     21 user         0.0   0.0   1.0M  --           --    └─ child2",
-			},
-		},
-		{
-			name:       "4 space indentation",
-			indentSize: 4,
-			expectedTree: []string{
-				"   PID     USER     %CPU  %MEM   RSS   START    TIME    COMMAND",
-				"--------------------------------------------------------------------------------",
-				"      1 root         0.0   0.0   1.0M  --           --  ─┬─── init",
-				"     10 user         0.0   0.0   1.0M  --           --   └───┬─── parent",
-				"     20 user         0.0   0.0   1.0M  --           --       ├───┬─── child1",
-				"     30 user         0.0   0.0   1.0M  --           --       │   └─────── grandchild",
-				"     21 user         0.0   0.0   1.0M  --           --       └─────── child2",
-			},
-		},
-		{
-			name:       "10 space indentation",
-			indentSize: 10,
-			expectedTree: []string{
-				"   PID     USER     %CPU  %MEM   RSS   START    TIME    COMMAND",
-				"--------------------------------------------------------------------------------",
-				"      1 root         0.0   0.0   1.0M  --           --  ─┬───────── init",
-				"     10 user         0.0   0.0   1.0M  --           --   └─────────┬───────── parent",
-				"     20 user         0.0   0.0   1.0M  --           --             ├─────────┬───────── child1",
-				"     30 user         0.0   0.0   1.0M  --           --             │         └─────────────────── grandchild",
-				"     21 user         0.0   0.0   1.0M  --           --             └─────────────────── child2",
-			},
-		},
+		{name: "match", cli: CLI{PIDs: []string{"10"}, Quiet: true}, platform: processes, expected: 0},
+		{name: "no filters", cli: CLI{Quiet: true}, platform: processes, expected: 0},
+		{name: "no match", cli: CLI{Users: []string{"nobody"}, Quiet: true}, platform: processes, expected: exitNoMatch},
+		{name: "failure", cli: CLI{PIDs: []string{"10"}, Quiet: true}, platform: failingPlatform{}, expected: exitFailure, stderr: "failed to get processes: ps not found\n"},
+		{name: "invalid pid", cli: CLI{PIDs: []string{"abc"}, Quiet: true}, platform: processes, expected: exitUsage, stderr: "invalid pid: abc\n"},
+		{name: "invalid sample", cli: CLI{Sample: -1}, platform: processes, expected: exitUsage, stderr: "invalid sample: -1ns (must be positive)\n"},
+		{name: "kill without match", cli: CLI{Users: []string{"nobody"}, Kill: KillCmd{Signal: "TERM", Yes: true}}, command: "kill", platform: processes, expected: exitNoMatch, stderr: "kill: no matching processes\n"},
+		{name: "kill without filters", cli: CLI{Kill: KillCmd{Signal: "TERM", Yes: true}}, command: "kill", platform: processes, expected: exitUsage, stderr: "kill: choose processes to signal, e.g. -p PID\n"},
+		{name: "kill failure", cli: CLI{PIDs: []string{"10"}, Kill: KillCmd{Signal: "TERM", Yes: true}}, command: "kill", platform: failingPlatform{}, expected: exitFailure, stderr: "kill: failed to get processes: ps not found\n"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Create a test Proktree instance with specified indentation
-			pt := &Proktree{
-				processes:   processes,
-				children:    pidToChildren,
-				skipPids:    skipPids,
-				maxUserLen:  10,
-				maxStartLen: 5,
-				maxTimeLen:  8,
-				termWidth:   0,
-				cli:         CLI{Indent: tt.indentSize},
+			if tt.command == "" {
+				tt.command = "tree"
 			}
-
-			// No filters - show all
-			pt.pidsToShow = nil
-
-			// Capture output
-			var buf strings.Builder
-			pt.printProcessTree(&buf, 1, true)
-
-			// Get lines from output
-			output := strings.TrimRight(buf.String(), "\n")
-			var lines []string
-			if output != "" {
-				lines = strings.Split(output, "\n")
-			}
-
-			// Compare output
-			if len(lines) != len(tt.expectedTree) {
-				t.Errorf("Expected %d lines, got %d", len(tt.expectedTree), len(lines))
-				t.Logf("Got:\n%s", strings.Join(lines, "\n"))
-				return
+			var stdout, stderr strings.Builder
+			if status := run(tt.cli, tt.command, tt.platform, strings.NewReader(""), &stdout, &stderr); status != tt.expected {
+				t.Errorf("run() = %d, want %d (stderr %q)", status, tt.expected, stderr.String())
 			}
-
-			for i, expected := range tt.expectedTree {
-				if i >= len(lines) {
-					t.Errorf("Missing line %d: expected %q", i, expected)
-					continue
-				}
-				if lines[i] != expected {
-					t.Errorf("Line %d mismatch:\ngot:      %q\nexpected: %q", i, lines[i], expected)
-				}
+			if stderr.String() != tt.stderr {
+				t.Errorf("stderr mismatch:\ngot:      %q\nexpected: %q", stderr.String(), tt.stderr)
 			}
 		})
 	}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/alecthomas/kong"
+	"github.com/jeremywohl/proktree/tree"
+)
+
+// ReniceCmd holds the args of the renice subcommand
+type ReniceCmd struct {
+	Priority nice   `short:"n" name:"priority" help:"Nice value, from -20 (most favorable) to 19 (least favorable)" required:""`
+	IOClass  string `name:"ionice" help:"Also set the I/O scheduling class (Linux only): idle, best-effort or realtime"`
+	IOLevel  int    `name:"ionice-level" help:"I/O priority for best-effort and realtime, from 0 (highest) to 7 (default: 4)" default:"4"`
+}
+
+// nice is a nice value flag that, unlike plain int flags, takes negative
+// values as renice(1) does, e.g. -n -5
+type nice int
+
+// Decode reads the next argument as the nice value, even if it looks like a flag
+func (n *nice) Decode(ctx *kong.DecodeContext) error {
+	token := ctx.Scan.Pop()
+	value, err := strconv.Atoi(fmt.Sprint(token.Value))
+	if err != nil {
+		return fmt.Errorf("expected a nice value but got %q", token)
+	}
+	*n = nice(value)
+	return nil
+}
+
+// ioClasses are the Linux I/O scheduling classes, by name
+var ioClasses = map[string]int{
+	"realtime":    1,
+	"best-effort": 2,
+	"idle":        3,
+}
+
+// reniceProcess sets the nice value, and the I/O class and level unless class
+// is empty, of a process; tests replace it
+var reniceProcess = func(pid, nice int, class string, level int) error {
+	if err := setNice(pid, nice); err != nil {
+		return err
+	}
+	if class != "" {
+		return setIOPriority(pid, ioClasses[class], level)
+	}
+	return nil
+}
+
+// runRenice changes the priority of the processes matching the CLI filters and
+// all their descendants, parents first
+func runRenice(cli CLI, platform tree.Platform, out io.Writer) error {
+	args := cli.Renice
+	if args.Priority < -20 || args.Priority > 19 {
+		return usagef("priority must be from -20 to 19, got %d", args.Priority)
+	}
+
+	action := fmt.Sprintf("nice %d", args.Priority)
+	if args.IOClass != "" {
+		if _, ok := ioClasses[args.IOClass]; !ok {
+			return usagef("unknown I/O class: %s (use idle, best-effort or realtime)", args.IOClass)
+		}
+		if args.IOLevel < 0 || args.IOLevel > 7 {
+			return usagef("I/O level must be from 0 to 7, got %d", args.IOLevel)
+		}
+		if args.IOClass == "idle" {
+			// The idle class has no levels
+			args.IOLevel = 0
+			action += ", ionice idle"
+		} else {
+			action += fmt.Sprintf(", ionice %s:%d", args.IOClass, args.IOLevel)
+		}
+	}
+
+	_, targets, err := subtreeTargets(cli, platform, "renice")
+	if err != nil {
+		return err
+	}
+
+	// Parents first, so children they fork meanwhile inherit the new priority
+	return applyProcesses(out, platform, targets, action, func(pid int) error {
+		return reniceProcess(pid, int(args.Priority), args.IOClass, args.IOLevel)
+	})
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+func TestRunRenice(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     ReniceCmd
+		expected []int // Reniced PIDs, in order
+		output   string
+		wantErr  string
+	}{
+		{
+			name:     "nice only, parents first",
+			args:     ReniceCmd{Priority: 10, IOLevel: 4},
+			expected: []int{11, 12},
+			output:   "     11  nice 10 cc\n     12  nice 10 cc1\n",
+		},
+		{
+			name:     "with ionice",
+			args:     ReniceCmd{Priority: 5, IOClass: "best-effort", IOLevel: 7},
+			expected: []int{11, 12},
+			output:   "     11  nice 5, ionice best-effort:7 cc\n     12  nice 5, ionice best-effort:7 cc1\n",
+		},
+		{
+			name:     "idle class has no level",
+			args:     ReniceCmd{Priority: 19, IOClass: "idle", IOLevel: 4},
+			expected: []int{11, 12},
+			output:   "     11  nice 19, ionice idle cc\n     12  nice 19, ionice idle cc1\n",
+		},
+		{
+			name:     "raising priority",
+			args:     ReniceCmd{Priority: -5},
+			expected: []int{11, 12},
+			output:   "     11  nice -5 cc\n     12  nice -5 cc1\n",
+		},
+		{
+			name:    "priority out of range",
+			args:    ReniceCmd{Priority: 20},
+			wantErr: "priority must be from -20 to 19, got 20",
+		},
+		{
+			name:    "unknown I/O class",
+			args:    ReniceCmd{IOClass: "fast"},
+			wantErr: "unknown I/O class: fast (use idle, best-effort or realtime)",
+		},
+		{
+			name:    "I/O level out of range",
+			args:    ReniceCmd{IOClass: "realtime", IOLevel: 8},
+			wantErr: "I/O level must be from 0 to 7, got 8",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pids []int
+			original := reniceProcess
+			reniceProcess = func(pid, nice int, class string, level int) error {
+				if nice != int(tt.args.Priority) || class != tt.args.IOClass {
+					t.Errorf("PID %d reniced to %d, %q", pid, nice, class)
+				}
+				if class == "idle" && level != 0 {
+					t.Errorf("PID %d idle level %d, want 0", pid, level)
+				}
+				pids = append(pids, pid)
+				return nil
+			}
+			defer func() { reniceProcess = original }()
+
+			cli := CLI{PIDs: []string{"11"}, Renice: tt.args}
+			var out strings.Builder
+			err := runRenice(cli, &sequencePlatform{lists: [][]tree.Process{killTestProcesses()}}, &out)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("runRenice() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("runRenice() error: %v", err)
+			}
+
+			if !slices.Equal(pids, tt.expected) {
+				t.Errorf("reniced %v, want %v", pids, tt.expected)
+			}
+			if out.String() != tt.output {
+				t.Errorf("output mismatch:\ngot:      %q\nexpected: %q", out.String(), tt.output)
+			}
+		})
+	}
+}
+
+func TestReniceNegativePriority(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected nice
+		wantErr  bool
+	}{
+		{args: []string{"renice", "-n", "-5", "-p", "11"}, expected: -5},
+		{args: []string{"renice", "--priority", "-20", "-p", "11"}, expected: -20},
+		{args: []string{"renice", "--priority=-1", "-p", "11"}, expected: -1},
+		{args: []string{"renice", "-n", "10", "-p", "11"}, expected: 10},
+		{args: []string{"renice", "-n", "-p", "11"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		cli, _, err := parseTestArgs(t, t.TempDir(), tt.args...)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%v) = %d, want error", tt.args, cli.Renice.Priority)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%v) error: %v", tt.args, err)
+		} else if cli.Renice.Priority != tt.expected {
+			t.Errorf("Parse(%v) priority = %d, want %d", tt.args, cli.Renice.Priority, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"time"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+// samplingPlatform replaces the lifetime average CPU usage that ps gives with
+// the usage over an interval, from two readings of CPU times, and optionally
+// measures I/O rates over it too
+type samplingPlatform struct {
+	tree.Platform
+	interval     time.Duration
+	io           bool // Read I/O, and its rates
+	sleep        func(time.Duration)
+	now          func() time.Time
+	readCPUTimes func([]tree.Process) (int, error)
+	readIO       func([]tree.Process) (int, error)
+}
+
+// newSamplingPlatform returns platform measuring CPU usage, and I/O if io is
+// set, over interval
+func newSamplingPlatform(platform tree.Platform, interval time.Duration, io bool) *samplingPlatform {
+	return &samplingPlatform{
+		Platform:     platform,
+		interval:     interval,
+		io:           io,
+		sleep:        time.Sleep,
+		now:          time.Now,
+		readCPUTimes: tree.ReadCPUTimes,
+		readIO:       tree.ReadIO,
+	}
+}
+
+func (s *samplingPlatform) GetProcesses() ([]tree.Process, error) {
+	before, start, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	s.sleep(s.interval)
+	after, end, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+
+	sampleCPU(before, after, end.Sub(start))
+	if s.io {
+		sampleIO(before, after, end.Sub(start))
+	}
+	return after, nil
+}
+
+// read returns the processes, with precise CPU times, and when they were read
+func (s *samplingPlatform) read() ([]tree.Process, time.Time, error) {
+	processes, err := s.Platform.GetProcesses()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	// Processes that exited since ps keep its CPU time
+	if _, err := s.readCPUTimes(processes); err != nil {
+		return nil, time.Time{}, err
+	}
+	if s.io {
+		if _, err := s.readIO(processes); err != nil {
+			return nil, time.Time{}, err
+		}
+	}
+	return processes, s.now(), nil
+}
+
+// sampleCPU sets the CPU usage of each process after to the share of elapsed
+// it spent on CPU since before
+func sampleCPU(before, after []tree.Process, elapsed time.Duration) {
+	if elapsed <= 0 {
+		return
+	}
+	previous := make(map[int]tree.Process, len(before))
+	for _, p := range before {
+		previous[p.PID] = p
+	}
+
+	for i := range after {
+		p := &after[i]
+		// Processes started since spent all their CPU time in the interval
+		used := p.CPUTime
+		if prior, ok := previous[p.PID]; ok && sameProcess(prior, *p) {
+			used -= prior.CPUTime
+		}
+		p.CPUPct = max(0, used.Seconds()/elapsed.Seconds()*100)
+	}
+}
+
+// sampleIO sets the I/O rates of each process after from its I/O since before.
+// Processes whose I/O was unreadable in either reading have no rates.
+func sampleIO(before, after []tree.Process, elapsed time.Duration) {
+	if elapsed <= 0 {
+		return
+	}
+	previous := make(map[int]tree.Process, len(before))
+	for _, p := range before {
+		previous[p.PID] = p
+	}
+
+	for i := range after {
+		p := &after[i]
+		if p.IO == nil {
+			continue
+		}
+		// Processes started since did all their I/O in the interval
+		read, written := p.IO.ReadBytes, p.IO.WriteBytes
+		if prior, ok := previous[p.PID]; ok && sameProcess(prior, *p) {
+			if prior.IO == nil {
+				p.IO = nil
+				continue
+			}
+			read -= prior.IO.ReadBytes
+			written -= prior.IO.WriteBytes
+		}
+		p.IO.ReadRate = max(0, read/elapsed.Seconds())
+		p.IO.WriteRate = max(0, written/elapsed.Seconds())
+	}
+}
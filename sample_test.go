@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+func TestSamplingPlatform(t *testing.T) {
+	started := time.Unix(1750000000, 0)
+	reused := time.Unix(1750000500, 0)
+	before := []tree.Process{
+		{PID: 1, Command: "/sbin/init", CPUPct: 0.1, StartTime: &started, CPUTime: 10 * time.Second},
+		{PID: 10, PPID: 1, Command: "make", CPUPct: 0.5, StartTime: &started, CPUTime: 2 * time.Second},
+		{PID: 11, PPID: 10, Command: "cc busy.c", CPUPct: 1, StartTime: &started, CPUTime: 20 * time.Second},
+		{PID: 12, PPID: 10, Command: "cc old.c", StartTime: &started, CPUTime: 30 * time.Second},
+		{PID: 20, PPID: 1, Command: "gone", CPUPct: 50, StartTime: &started, CPUTime: time.Hour},
+	}
+	after := []tree.Process{
+		{PID: 1, Command: "/sbin/init", CPUPct: 0.1, StartTime: &started, CPUTime: 10 * time.Second},
+		{PID: 10, PPID: 1, Command: "make", CPUPct: 0.5, StartTime: &started, CPUTime: 2100 * time.Millisecond},
+		{PID: 11, PPID: 10, Command: "cc busy.c", CPUPct: 1, StartTime: &started, CPUTime: 21800 * time.Millisecond},
+		{PID: 12, PPID: 10, Command: "cc new.c", StartTime: &reused, CPUTime: 500 * time.Millisecond}, // PID reused
+		{PID: 13, PPID: 10, Command: "cc fresh.c", CPUTime: 250 * time.Millisecond},                   // Started since
+	}
+
+	var slept []time.Duration
+	clock := time.Unix(1750001000, 0)
+	platform := newSamplingPlatform(&sequencePlatform{lists: [][]tree.Process{before, after}}, 2*time.Second, false)
+	platform.sleep = func(d time.Duration) {
+		slept = append(slept, d)
+		clock = clock.Add(d)
+	}
+	platform.now = func() time.Time { return clock }
+	platform.readCPUTimes = func([]tree.Process) (int, error) { return 0, nil }
+
+	processes, err := platform.GetProcesses()
+	if err != nil {
+		t.Fatalf("GetProcesses() error: %v", err)
+	}
+	if len(slept) != 1 || slept[0] != 2*time.Second {
+		t.Errorf("slept %v, want [2s]", slept)
+	}
+
+	expected := map[int]float64{1: 0, 10: 5, 11: 90, 12: 25, 13: 12.5}
+	for _, p := range processes {
+		if got := p.CPUPct; got < expected[p.PID]-1e-9 || got > expected[p.PID]+1e-9 {
+			t.Errorf("PID %d CPUPct = %v, want %v", p.PID, got, expected[p.PID])
+		}
+	}
+
+	pt := newTestProktree(t, CLI{Columns: []string{"subcpu"}}, processes)
+	if got := pt.subtreeCPU(10); got < 132.5-1e-9 || got > 132.5+1e-9 {
+		t.Errorf("subtreeCPU(10) = %v, want 132.5", got)
+	}
+
+	var buf bytes.Buffer
+	if err := pt.printTrees(&buf); err != nil {
+		t.Fatalf("printTrees() error: %v", err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	if !strings.Contains(lines[0], "SUB%CPU") {
+		t.Errorf("header lacks SUB%%CPU: %q", lines[0])
+	}
+	for _, line := range lines {
+		if !strings.HasSuffix(line, "make") {
+			continue
+		}
+		if fields := strings.Fields(line); fields[1] != "5.0" || fields[6] != "132.5" {
+			t.Errorf("make line mismatch:\ngot:      %q\nexpected: %%CPU 5.0 and SUB%%CPU 132.5", line)
+		}
+	}
+}
+
+func TestSampleCPUNoTime(t *testing.T) {
+	processes := []tree.Process{{PID: 1, CPUPct: 3, CPUTime: time.Second}}
+	sampleCPU(nil, processes, 0)
+	if processes[0].CPUPct != 3 {
+		t.Errorf("sampleCPU() over no time changed CPUPct to %v", processes[0].CPUPct)
+	}
+}
+
+func TestSampleIO(t *testing.T) {
+	started := time.Unix(1750000000, 0)
+	before := []tree.Process{
+		{PID: 10, Command: "postgres", StartTime: &started, IO: &tree.IO{ReadBytes: 1 << 20, WriteBytes: 4096}},
+		{PID: 11, Command: "sshd", StartTime: &started},
+	}
+	after := []tree.Process{
+		{PID: 10, Command: "postgres", StartTime: &started, IO: &tree.IO{ReadBytes: 5 << 20, WriteBytes: 4096}},
+		{PID: 11, Command: "sshd", StartTime: &started, IO: &tree.IO{ReadBytes: 1 << 20}}, // Unreadable before
+		{PID: 12, Command: "unreadable", StartTime: &started},
+		{PID: 13, Command: "cp a b", IO: &tree.IO{WriteBytes: 2 << 20}}, // Started since
+	}
+	sampleIO(before, after, 2*time.Second)
+
+	if io := after[0].IO; io.ReadRate != 2<<20 || io.WriteRate != 0 || io.ReadBytes != 5<<20 {
+		t.Errorf("postgres IO = %+v, want 2M/s read", io)
+	}
+	if after[1].IO != nil || after[2].IO != nil {
+		t.Errorf("IO of processes unreadable in either reading = %+v, %+v, want nil", after[1].IO, after[2].IO)
+	}
+	if io := after[3].IO; io.WriteRate != 1<<20 {
+		t.Errorf("cp IO = %+v, want 1M/s written", io)
+	}
+}
+
+func TestIOColumns(t *testing.T) {
+	processes := []tree.Process{
+		{PID: 1, Command: "/sbin/init"}, // Unreadable
+		{PID: 100, PPID: 1, Command: "postgres", IO: &tree.IO{ReadBytes: 3 << 30, WriteBytes: 1 << 30, ReadRate: 512, WriteRate: 1 << 20}},
+		{PID: 101, PPID: 100, Command: "postgres: checkpointer", IO: &tree.IO{WriteBytes: 512 << 20, WriteRate: 3 << 20}},
+	}
+	columns := []string{"read", "write", "subread", "subwrite"}
+
+	tests := []struct {
+		name     string
+		cli      CLI
+		pid      int
+		expected []string
+	}{
+		{"cumulative", CLI{Columns: columns}, 100, []string{"3.0G", "1.0G", "3.0G", "1.5G"}},
+		{"sampled", CLI{Columns: columns, Sample: time.Second}, 100, []string{"512B/s", "1.0M/s", "512B/s", "4.0M/s"}},
+		{"unreadable", CLI{Columns: columns}, 1, []string{"?", "?", "~3.0G", "~1.5G"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pt := newTestProktree(t, tt.cli, processes)
+			var got []string
+			for _, column := range pt.renderer.Columns {
+				got = append(got, column.Value(pt.tree.Process(tt.pid)))
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("PID %d columns = %q, want %q", tt.pid, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+// ServeCmd holds the args of the serve subcommand
+type ServeCmd struct {
+	Listen   string        `short:"l" name:"listen" help:"Address to listen on: HOST:PORT, or unix:PATH for a Unix socket (default: 127.0.0.1:9256)" default:"127.0.0.1:9256"`
+	Interval time.Duration `name:"interval" help:"Reuse the process list for this long before collecting it again; 0 to collect on every request (default: 5s)" default:"5s"`
+}
+
+// runServe serves metrics and the JSON API for processes matching the CLI
+// filters until interrupted
+func runServe(cli CLI, platform tree.Platform) error {
+	listener, err := listen(cli.Serve.Listen)
+	if err != nil {
+		return err
+	}
+
+	// All endpoints share one process list, collected at most once per interval
+	platform = newProcessCache(platform, cli.Serve.Interval)
+	api := apiHandler(cli, platform)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metricsHandler(cli, platform))
+	mux.Handle("/tree", api)
+	mux.Handle("/process/", api)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, "proktree %s\n\n"+
+			"/metrics                    Prometheus metrics\n"+
+			"/tree                       Process trees as JSON; filter with ?pid=, ?user=, ?string=, ?string-insensitive=\n"+
+			"/process/{pid}              A process and its descendants as a JSON tree\n"+
+			"/process/{pid}/ancestors    A process's ancestors as JSON, parent first\n"+
+			"/process/{pid}/descendants  A process's descendants as JSON\n", Version)
+	})
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Shut down cleanly on interrupt, which also removes a Unix socket
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(os.Stderr, "proktree: serving on %s\n", cli.Serve.Listen)
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// listen opens a TCP listener for HOST:PORT, or a Unix socket listener for unix:PATH
+func listen(address string) (net.Listener, error) {
+	path, isUnix := strings.CutPrefix(address, "unix:")
+	if !isUnix {
+		return net.Listen("tcp", address)
+	}
+
+	// Replace a stale socket left by a previous run, but nothing else
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is in use", path)
+		}
+		os.Remove(path)
+	}
+	return net.Listen("unix", path)
+}
+
+// snapshot collects the current processes and applies the CLI filters
+func snapshot(cli CLI, platform tree.Platform) (*Proktree, error) {
+	processList, err := platform.GetProcesses()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get processes: %v", err)
+	}
+
+	pt := newProktree(cli)
+	if err := pt.load(processList); err != nil {
+		return nil, err
+	}
+	return pt, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListenUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "proktree.sock")
+
+	listener, err := listen("unix:" + path)
+	if err != nil {
+		t.Fatalf("listen() error: %v", err)
+	}
+
+	// A live socket is not replaced
+	if _, err := listen("unix:" + path); err == nil {
+		t.Errorf("listen() on a socket in use succeeded, want error")
+	}
+	listener.Close()
+
+	// A stale socket is replaced
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("net.Listen() error: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+	listener, err = listen("unix:" + path)
+	if err != nil {
+		t.Fatalf("listen() over a stale socket error: %v", err)
+	}
+	listener.Close()
+
+	// Other files are left alone
+	regular := filepath.Join(t.TempDir(), "regular")
+	if err := os.WriteFile(regular, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listen("unix:" + regular); err == nil {
+		t.Errorf("listen() over a regular file succeeded, want error")
+	}
+}
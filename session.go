@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+// bySession rearranges processes by session: session leaders become roots,
+// with the other processes of their session under them, below their parent
+// if it's in the session too. Sessions whose leader exited get a placeholder
+// leader, collecting what they left behind. Kernel threads, in no session,
+// and processes whose session is unknown stay where they are.
+func bySession(processes []tree.Process) []tree.Process {
+	exists := make(map[int]bool)
+	sids := make(map[int]int) // By PID
+	for _, p := range processes {
+		exists[p.PID] = true
+		if p.Session != nil {
+			sids[p.PID] = p.Session.SID
+		}
+	}
+
+	arranged := make([]tree.Process, 0, len(processes))
+	var placeholders []tree.Process
+	for _, p := range processes {
+		switch {
+		case p.Session == nil || p.Session.SID == 0:
+		case p.Leader():
+			p.PPID = 0
+		case exists[p.PPID] && sids[p.PPID] == p.Session.SID:
+		default:
+			sid := p.Session.SID
+			p.PPID = sid
+			// No process reuses a PID while it identifies a session
+			if !exists[sid] {
+				exists[sid] = true
+				placeholders = append(placeholders, tree.Process{
+					PID:     sid,
+					User:    p.User,
+					Session: &tree.Session{SID: sid, PGID: sid, TTY: p.Session.TTY},
+					Command: fmt.Sprintf("(session %d, leader exited)", sid),
+				})
+			}
+		}
+		arranged = append(arranged, p)
+	}
+	return append(arranged, placeholders...)
+}
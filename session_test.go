@@ -0,0 +1,127 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+// sessionTestProcesses are an SSH login, a job left behind by a disconnected
+// session, and some daemons
+func sessionTestProcesses() []tree.Process {
+	s := func(sid, pgid int, tty string) *tree.Session { return &tree.Session{SID: sid, PGID: pgid, TTY: tty} }
+	return []tree.Process{
+		{PID: 1, User: "root", Command: "/sbin/init", Session: s(1, 1, "")},
+		{PID: 2, User: "root", Command: "[kthreadd]", Session: s(0, 0, "")},
+		{PID: 50, PPID: 2, User: "root", Command: "[kworker/0:1]", Session: s(0, 0, "")},
+		{PID: 600, PPID: 1, User: "root", Command: "sshd: /usr/sbin/sshd -D", Session: s(600, 600, "")},
+		{PID: 700, PPID: 600, User: "root", Command: "sshd: alice [priv]", Session: s(700, 700, "")},
+		{PID: 710, PPID: 700, User: "alice", Command: "sshd: alice@pts/3", Session: s(700, 700, "")},
+		{PID: 711, PPID: 710, User: "alice", Command: "-bash", Session: s(711, 711, "pts/3")},
+		{PID: 720, PPID: 711, User: "alice", Command: "make -j4", Session: s(711, 720, "pts/3")},
+		{PID: 721, PPID: 720, User: "alice", Command: "cc1 main.c", Session: s(711, 720, "pts/3")},
+		{PID: 800, PPID: 1, User: "bob", Command: "python3 train.py", Session: s(790, 795, "")},
+		{PID: 801, PPID: 800, User: "bob", Command: "python3 -c worker", Session: s(790, 795, "")},
+		{PID: 805, PPID: 1, User: "bob", Command: "tail -f log", Session: s(790, 805, "")},
+		{PID: 900, PPID: 1, User: "root", Command: "cron", Session: s(900, 900, "")},
+		{PID: 950, PPID: 1, User: "root", Command: "agetty tty1"}, // Unreadable
+	}
+}
+
+func TestBySession(t *testing.T) {
+	tests := []struct {
+		name     string
+		cli      CLI
+		expected []string
+	}{
+		{
+			name: "sessions",
+			cli:  CLI{BySession: true},
+			expected: []string{
+				"1 - ─┬─ /sbin/init",
+				"950 ?  └─── agetty tty1",
+				"2 - ─┬─ [kthreadd]",
+				"50 -  └─── [kworker/0:1]",
+				"600 - ─── sshd: /usr/sbin/sshd -D",
+				"700 - ─┬─ sshd: alice [priv]",
+				"710 -  └─── sshd: alice@pts/3",
+				"711 pts/3 ─┬─ -bash",
+				"720 pts/3  └─┬─ make -j4",
+				"721 pts/3    └─── cc1 main.c",
+				"790 - ─┬─ (session 790, leader exited)",
+				"800 -  ├─┬─ python3 train.py",
+				"801 -  │ └─── python3 -c worker",
+				"805 -  └─── tail -f log",
+				"900 - ─── cron",
+			},
+		},
+		{
+			name: "sessions of a user",
+			cli:  CLI{BySession: true, Users: []string{"bob"}},
+			expected: []string{
+				"790 - ─┬─ (session 790, leader exited)",
+				"800 -  ├─┬─ python3 train.py",
+				"801 -  │ └─── python3 -c worker",
+				"805 -  └─── tail -f log",
+			},
+		},
+		{
+			name: "terminal",
+			cli:  CLI{TTYs: []string{"/dev/pts/3"}},
+			expected: []string{
+				"1 - ─┬─ /sbin/init",
+				"600 -  └─┬─ sshd: /usr/sbin/sshd -D",
+				"700 -    └─┬─ sshd: alice [priv]",
+				"710 -      └─┬─ sshd: alice@pts/3",
+				"711 pts/3        └─┬─ -bash",
+				"720 pts/3          └─┬─ make -j4",
+				"721 pts/3            └─── cc1 main.c",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pt := newTestProktree(t, tt.cli, sessionTestProcesses())
+			if err := pt.parseLineTemplate(`{{.PID}} {{with .Session}}{{or .TTY "-"}}{{else}}?{{end}} {{.Tree}}{{.Command}}`); err != nil {
+				t.Fatalf("parseLineTemplate() error: %v", err)
+			}
+
+			var buf strings.Builder
+			if err := pt.printTrees(&buf); err != nil {
+				t.Fatalf("printTrees() error: %v", err)
+			}
+
+			lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+			if len(lines) != len(tt.expected) {
+				t.Fatalf("Expected %d lines, got %d:\n%s", len(tt.expected), len(lines), buf.String())
+			}
+			for i, expected := range tt.expected {
+				if lines[i] != expected {
+					t.Errorf("Line %d mismatch:\ngot:      %q\nexpected: %q", i, lines[i], expected)
+				}
+			}
+		})
+	}
+}
+
+func TestSessionColumns(t *testing.T) {
+	processes := sessionTestProcesses()
+	for _, tt := range []struct {
+		p        tree.Process
+		expected []string
+	}{
+		{processes[7], []string{"711", "720", "pts/3"}},
+		{processes[12], []string{"900", "900", "-"}},
+		{processes[13], []string{"?", "?", "?"}},
+	} {
+		var got []string
+		for _, name := range []string{"sid", "pgid", "tty"} {
+			got = append(got, columns[name].Value(&tt.p))
+		}
+		if strings.Join(got, " ") != strings.Join(tt.expected, " ") {
+			t.Errorf("PID %d columns = %q, want %q", tt.p.PID, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io"
+	"syscall"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+// runStop pauses the processes matching the CLI filters and all their
+// descendants, parents first so none can react to a stopped child
+func runStop(cli CLI, platform tree.Platform, out io.Writer) error {
+	if !signalsSupported {
+		return errSignalsUnsupported
+	}
+	_, targets, err := subtreeTargets(cli, platform, "signal")
+	if err != nil {
+		return err
+	}
+	return changeState(out, platform, targets, sigStop, true)
+}
+
+// runCont resumes the processes matching the CLI filters and all their
+// descendants, leaves first, reversing the order of runStop
+func runCont(cli CLI, platform tree.Platform, out io.Writer) error {
+	if !signalsSupported {
+		return errSignalsUnsupported
+	}
+	_, targets, err := subtreeTargets(cli, platform, "signal")
+	if err != nil {
+		return err
+	}
+	return changeState(out, platform, leavesFirst(targets), sigCont, false)
+}
+
+// processStopped reports whether a process is stopped; tests replace it
+var processStopped = tree.Stopped
+
+// changeState sends sig to each process in order, to stop it or resume it,
+// skipping those already stopped or running, so that only processes whose
+// state changes are reported as signaled. Processes whose state can't be
+// read are signaled anyway.
+func changeState(out io.Writer, platform tree.Platform, processes []tree.Process, sig syscall.Signal, stop bool) error {
+	return applyProcesses(out, platform, processes, signalName(sig), func(pid int) error {
+		if stopped, err := processStopped(pid); err == nil && stopped == stop {
+			if stop {
+				return skippedError("already stopped")
+			}
+			return skippedError("already running")
+		}
+		return killProcess(pid, sig)
+	})
+}
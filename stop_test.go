@@ -0,0 +1,127 @@
+//go:build unix
+
+package main
+
+import (
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+func TestRunStopCont(t *testing.T) {
+	tests := []struct {
+		name     string
+		run      func(cli CLI, platform tree.Platform, out *strings.Builder) error
+		sig      syscall.Signal
+		stopped  map[int]bool // Stopped before running, by PID
+		expected []int
+		output   string
+	}{
+		{
+			name: "stop parents first",
+			run: func(cli CLI, platform tree.Platform, out *strings.Builder) error {
+				return runStop(cli, platform, out)
+			},
+			sig:      sigStop,
+			expected: []int{10, 11, 12, 13},
+			output:   "     10  SIGSTOP make\n     11  SIGSTOP cc\n     12  SIGSTOP cc1\n     13  SIGSTOP cc\n",
+		},
+		{
+			name: "cont leaves first",
+			run: func(cli CLI, platform tree.Platform, out *strings.Builder) error {
+				return runCont(cli, platform, out)
+			},
+			sig:      sigCont,
+			stopped:  map[int]bool{10: true, 11: true, 12: true, 13: true},
+			expected: []int{13, 12, 11, 10},
+			output:   "     13  SIGCONT cc\n     12  SIGCONT cc1\n     11  SIGCONT cc\n     10  SIGCONT make\n",
+		},
+		{
+			name: "stop skips stopped processes",
+			run: func(cli CLI, platform tree.Platform, out *strings.Builder) error {
+				return runStop(cli, platform, out)
+			},
+			sig:      sigStop,
+			stopped:  map[int]bool{12: true},
+			expected: []int{10, 11, 13},
+			output:   "     10  SIGSTOP make\n     11  SIGSTOP cc\n     12  skipped, already stopped\n     13  SIGSTOP cc\n",
+		},
+		{
+			name: "cont skips running processes",
+			run: func(cli CLI, platform tree.Platform, out *strings.Builder) error {
+				return runCont(cli, platform, out)
+			},
+			sig:      sigCont,
+			stopped:  map[int]bool{10: true, 12: true},
+			expected: []int{12, 10},
+			output:   "     13  skipped, already running\n     12  SIGCONT cc1\n     11  skipped, already running\n     10  SIGCONT make\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := recordSignals(t, nil)
+			stoppedAs(t, tt.stopped)
+			platform := &sequencePlatform{lists: [][]tree.Process{killTestProcesses()}}
+
+			var out strings.Builder
+			if err := tt.run(CLI{PIDs: []string{"10"}}, platform, &out); err != nil {
+				t.Fatalf("error: %v", err)
+			}
+
+			var pids []int
+			for _, s := range *sent {
+				pids = append(pids, s.pid)
+				if s.sig != tt.sig {
+					t.Errorf("PID %d sent %v, want %v", s.pid, s.sig, tt.sig)
+				}
+			}
+			if !slices.Equal(pids, tt.expected) {
+				t.Errorf("signaled %v, want %v", pids, tt.expected)
+			}
+			if out.String() != tt.output {
+				t.Errorf("output mismatch:\ngot:      %q\nexpected: %q", out.String(), tt.output)
+			}
+		})
+	}
+
+	// The shell proktree runs in, and what's above it, are never stopped
+	t.Run("ancestors of proktree untouched", func(t *testing.T) {
+		sent := recordSignals(t, nil)
+		stoppedAs(t, nil)
+		runningUnder(t, 11)
+		var out strings.Builder
+		if err := runStop(CLI{PIDs: []string{"10"}}, &sequencePlatform{lists: [][]tree.Process{killTestProcesses()}}, &out); err != nil {
+			t.Fatalf("runStop() error: %v", err)
+		}
+		var pids []int
+		for _, s := range *sent {
+			pids = append(pids, s.pid)
+		}
+		if !slices.Equal(pids, []int{12, 13}) {
+			t.Errorf("signaled %v, want [12 13]", pids)
+		}
+	})
+
+	// Without filters, nothing is signaled
+	sent := recordSignals(t, nil)
+	var out strings.Builder
+	if err := runStop(CLI{}, &sequencePlatform{lists: [][]tree.Process{killTestProcesses()}}, &out); err == nil {
+		t.Errorf("runStop() without filters succeeded, want error")
+	}
+	if len(*sent) != 0 {
+		t.Errorf("signaled %v without filters", *sent)
+	}
+}
+
+// stoppedAs makes the processes of stopped, by PID, the stopped ones for the
+// duration of the test
+func stoppedAs(t *testing.T, stopped map[int]bool) {
+	t.Helper()
+	original := processStopped
+	processStopped = func(pid int) (bool, error) { return stopped[pid], nil }
+	t.Cleanup(func() { processStopped = original })
+}
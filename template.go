@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+// templateLine is the data available to --format templates. Every Process
+// field is promoted, so {{.PID}}, {{.User}}, {{.Command}} etc. work directly.
+type templateLine struct {
+	*tree.Process
+	Tree    string // Tree graphics for the line, aligned like the default output and with a trailing space
+	Depth   int    // 0 for roots
+	Matched bool   // True if the process itself matched the filters
+	Orphan  bool   // True if the process is a likely orphan, with --orphans or --mark-orphans
+}
+
+// templateFuncs returns the helper functions available to --format templates
+func (pt *Proktree) templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"rss":     tree.FormatRSS,
+		"bytes":   tree.FormatBytes,
+		"cputime": tree.FormatCPUTime,
+		"start":   pt.renderer.FormatStartTime,
+		"user":    pt.renderer.TruncateUser,
+		"age": func(startTime *time.Time) string {
+			if startTime == nil {
+				return "--"
+			}
+			return pt.now().Sub(*startTime).Round(time.Second).String()
+		},
+		"duration": func(d time.Duration) string {
+			return d.Round(time.Second).String()
+		},
+		"trunc": func(n int, s string) string {
+			if n <= 3 {
+				return s
+			}
+			return tree.TruncateWidth(s, n)
+		},
+		"pad":  func(n int, s string) string { return tree.PadRight(s, n) },
+		"lpad": func(n int, s string) string { return tree.PadLeft(s, n) },
+		"trim": strings.TrimSpace,
+	}
+}
+
+// parseLineTemplate parses a --format template, checks it against a sample
+// process, and sets the renderer to format lines with it
+func (pt *Proktree) parseLineTemplate(format string) error {
+	tmpl, err := template.New("format").Funcs(pt.templateFuncs()).Parse(format)
+	if err != nil {
+		return err
+	}
+
+	// Catch references to unknown fields now, rather than on every line
+	now := pt.now()
+	sample := templateLine{Process: &tree.Process{StartTime: &now}}
+	if err := tmpl.Execute(io.Discard, sample); err != nil {
+		return err
+	}
+
+	pt.renderer.LineFormat = func(line tree.Line) string {
+		return pt.executeLineTemplate(tmpl, line)
+	}
+	return nil
+}
+
+// executeLineTemplate formats one process line with a --format template
+func (pt *Proktree) executeLineTemplate(tmpl *template.Template, line tree.Line) string {
+	// Indent children one column so their branches hang under the root's
+	treeStr := line.Branch + " "
+	if line.Depth > 0 {
+		treeStr = " " + treeStr
+	}
+
+	var sb strings.Builder
+	err := tmpl.Execute(&sb, templateLine{
+		Process: line.Process,
+		Tree:    treeStr,
+		Depth:   line.Depth,
+		Matched: pt.tree.Matched(line.Process.PID),
+		Orphan:  pt.orphans[line.Process.PID],
+	})
+	if err != nil {
+		return fmt.Sprintf("%d: format error: %v", line.Process.PID, err)
+	}
+
+	// A line template must produce a single line
+	return strings.ReplaceAll(sb.String(), "\n", " ")
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+func TestLineTemplate(t *testing.T) {
+	testNow := time.Date(2025, 7, 16, 10, 0, 0, 0, time.UTC)
+	started := time.Date(2025, 7, 16, 8, 30, 0, 0, time.UTC)
+
+	processes := []tree.Process{
+		{PID: 1, PPID: 0, User: "root", RSSKB: 2048, Command: "init"},
+		{PID: 10, PPID: 1, User: "verylongusername", StartTime: &started, CPUTime: 65 * time.Second, Command: "bash --login"},
+		{PID: 11, PPID: 10, User: "alice", Command: "make -j8 all"},
+		{PID: 20, PPID: 1, User: "bob", Command: "vim"},
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		cli      CLI
+		expected []string
+	}{
+		{
+			name:   "fields and tree",
+			format: "{{.PID}} {{.User}} {{.Tree}}{{.Command}}",
+			expected: []string{
+				"1 root ─┬─ init",
+				"10 verylongusername  ├─┬─ bash --login",
+				"11 alice  │ └─── make -j8 all",
+				"20 bob  └─── vim",
+			},
+		},
+		{
+			name:   "helper functions",
+			format: "{{lpad 3 (print .PID)}} {{user .User | pad 10}} {{rss .RSSKB}} {{start .StartTime}} {{age .StartTime}} {{duration .CPUTime}} {{trunc 8 .Command}}",
+			expected: []string{
+				"  1 root       2.0M -- -- 0s init",
+				" 10 verylon... 0.0M 08:30 1h30m0s 1m5s bash ...",
+				" 11 alice      0.0M -- -- 0s make ...",
+				" 20 bob        0.0M -- -- 0s vim",
+			},
+		},
+		{
+			name:   "depth and matched",
+			format: "{{.Depth}} {{if .Matched}}*{{else}}-{{end}} {{.Command}}",
+			cli:    CLI{SearchStrings: []string{"bash"}},
+			expected: []string{
+				"0 - init",
+				"1 * bash --login",
+				"2 - make -j8 all",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCLI := tt.cli
+			testCLI.Indent = 2
+			pt := newTestProktree(t, testCLI, processes)
+			pt.renderer.Now = func() time.Time { return testNow }
+			if err := pt.parseLineTemplate(tt.format); err != nil {
+				t.Fatalf("parseLineTemplate(%q) error: %v", tt.format, err)
+			}
+
+			var buf strings.Builder
+			if err := pt.printTrees(&buf); err != nil {
+				t.Fatalf("printTrees() error: %v", err)
+			}
+
+			lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+			if len(lines) != len(tt.expected) {
+				t.Fatalf("Expected %d lines, got %d:\n%s", len(tt.expected), len(lines), buf.String())
+			}
+			for i, expected := range tt.expected {
+				if lines[i] != expected {
+					t.Errorf("Line %d mismatch:\ngot:      %q\nexpected: %q", i, lines[i], expected)
+				}
+			}
+		})
+	}
+}
+
+func TestParseLineTemplateErrors(t *testing.T) {
+	for _, format := range []string{
+		"{{.PID",
+		"{{.NoSuchField}}",
+		"{{nosuchfunc .PID}}",
+	} {
+		pt := newProktree(CLI{})
+		if err := pt.parseLineTemplate(format); err == nil {
+			t.Errorf("parseLineTemplate(%q) succeeded, want error", format)
+		}
+		if pt.renderer.LineFormat != nil {
+			t.Errorf("parseLineTemplate(%q) set a template despite the error", format)
+		}
+	}
+}
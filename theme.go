@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jeremywohl/proktree/tree"
+	"golang.org/x/term"
+)
+
+// themes are the built-in color themes, by name
+var themes = map[string]func() *tree.Theme{
+	// For dark terminal backgrounds
+	"default": func() *tree.Theme {
+		return &tree.Theme{
+			Header:  "1",
+			Glyphs:  "2",
+			Matched: "1;33",
+			CPU:     tree.Thresholds{Warn: 50, High: 90, WarnColor: "33", HighColor: "1;31"},
+			Mem:     tree.Thresholds{Warn: 10, High: 25, WarnColor: "33", HighColor: "1;31"},
+			Users:   []string{"36", "32", "34", "35"},
+		}
+	},
+	// For light terminal backgrounds, avoiding yellow
+	"light": func() *tree.Theme {
+		return &tree.Theme{
+			Header:  "1",
+			Glyphs:  "2",
+			Matched: "1;34",
+			CPU:     tree.Thresholds{Warn: 50, High: 90, WarnColor: "35", HighColor: "1;31"},
+			Mem:     tree.Thresholds{Warn: 10, High: 25, WarnColor: "35", HighColor: "1;31"},
+			Users:   []string{"36", "32", "34", "35"},
+		}
+	},
+	// Only matches and tree graphics
+	"minimal": func() *tree.Theme {
+		return &tree.Theme{
+			Glyphs:  "2",
+			Matched: "1",
+		}
+	},
+}
+
+// colorTheme returns the theme to render with, or nil if color is off
+func colorTheme(cli CLI) (*tree.Theme, error) {
+	name := cli.Theme
+	if name == "" {
+		name = "default"
+	}
+	newTheme, ok := themes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown theme: %s", name)
+	}
+	theme := newTheme()
+
+	// Check the colors even when color is off, so mistakes show up
+	colors := cli.Colors
+	if colors == "" {
+		colors = os.Getenv("PROKTREE_COLORS")
+	}
+	if err := parseColors(theme, colors); err != nil {
+		return nil, err
+	}
+
+	if !colorEnabled(cli.Color, term.IsTerminal(int(os.Stdout.Fd()))) {
+		return nil, nil
+	}
+	return theme, nil
+}
+
+// colorEnabled decides whether to color output. In auto mode, color is used
+// on a terminal, unless NO_COLOR is set or the terminal is dumb.
+func colorEnabled(mode string, isTerminal bool) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	default:
+		return isTerminal && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb"
+	}
+}
+
+// parseColors applies color overrides to theme. Entries are separated by
+// colons, e.g. "matched=1;33:glyphs=2:users=32,34:user.root=31:cpu-high-at=80".
+func parseColors(theme *tree.Theme, spec string) error {
+	for _, entry := range strings.Split(spec, ":") {
+		if entry == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return fmt.Errorf("invalid color entry: %s (want KEY=VALUE)", entry)
+		}
+
+		// Thresholds are percentages; everything else is a color
+		if threshold := colorThreshold(theme, key); threshold != nil {
+			pct, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid percentage for %s: %s", key, value)
+			}
+			*threshold = pct
+			continue
+		}
+
+		var colors []string
+		if key == "users" {
+			colors = strings.Split(value, ",")
+		} else {
+			colors = []string{value}
+		}
+		for _, color := range colors {
+			if strings.Trim(color, "0123456789;") != "" {
+				return fmt.Errorf("invalid color for %s: %s (want SGR parameters, e.g. 1;33)", key, color)
+			}
+		}
+
+		switch {
+		case key == "header":
+			theme.Header = value
+		case key == "glyphs":
+			theme.Glyphs = value
+		case key == "matched":
+			theme.Matched = value
+		case key == "cpu-warn":
+			theme.CPU.WarnColor = value
+		case key == "cpu-high":
+			theme.CPU.HighColor = value
+		case key == "mem-warn":
+			theme.Mem.WarnColor = value
+		case key == "mem-high":
+			theme.Mem.HighColor = value
+		case key == "users":
+			theme.Users = nil
+			if value != "" {
+				theme.Users = colors
+			}
+		case strings.HasPrefix(key, "user."):
+			if theme.User == nil {
+				theme.User = make(map[string]string)
+			}
+			theme.User[strings.TrimPrefix(key, "user.")] = value
+		default:
+			return fmt.Errorf("unknown color key: %s", key)
+		}
+	}
+	return nil
+}
+
+// colorThreshold returns the threshold named by key, or nil if key isn't one
+func colorThreshold(theme *tree.Theme, key string) *float64 {
+	switch key {
+	case "cpu-warn-at":
+		return &theme.CPU.Warn
+	case "cpu-high-at":
+		return &theme.CPU.High
+	case "mem-warn-at":
+		return &theme.Mem.Warn
+	case "mem-high-at":
+		return &theme.Mem.High
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+func TestColorEnabled(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       string
+		isTerminal bool
+		noColor    string
+		term       string
+		expected   bool
+	}{
+		{"auto on a terminal", "auto", true, "", "xterm", true},
+		{"auto when piped", "auto", false, "", "xterm", false},
+		{"auto with NO_COLOR", "auto", true, "1", "xterm", false},
+		{"auto on a dumb terminal", "auto", true, "", "dumb", false},
+		{"unset mode is auto", "", true, "", "xterm", true},
+		{"always overrides NO_COLOR", "always", false, "1", "dumb", true},
+		{"never", "never", true, "", "xterm", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColor)
+			t.Setenv("TERM", tt.term)
+			if got := colorEnabled(tt.mode, tt.isTerminal); got != tt.expected {
+				t.Errorf("colorEnabled(%q, %v) = %v, want %v", tt.mode, tt.isTerminal, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseColors(t *testing.T) {
+	theme := themes["default"]()
+	spec := "matched=1;35:glyphs=:users=31,32:user.root=1;31:cpu-high-at=80:mem-warn=36"
+	if err := parseColors(theme, spec); err != nil {
+		t.Fatalf("parseColors() error: %v", err)
+	}
+
+	expected := themes["default"]()
+	expected.Matched = "1;35"
+	expected.Glyphs = ""
+	expected.Users = []string{"31", "32"}
+	expected.User = map[string]string{"root": "1;31"}
+	expected.CPU.High = 80
+	expected.Mem.WarnColor = "36"
+
+	if theme.Matched != expected.Matched || theme.Glyphs != expected.Glyphs ||
+		!slices.Equal(theme.Users, expected.Users) || theme.User["root"] != "1;31" ||
+		theme.CPU != expected.CPU || theme.Mem != expected.Mem || theme.Header != expected.Header {
+		t.Errorf("parseColors() theme = %+v, want %+v", theme, expected)
+	}
+
+	// Built-in themes are not modified
+	if themes["default"]().Matched != "1;33" {
+		t.Errorf("parseColors() modified the built-in theme")
+	}
+
+	for _, tt := range []struct {
+		spec    string
+		wantErr string
+	}{
+		{"matched", "invalid color entry: matched (want KEY=VALUE)"},
+		{"matched=red", "invalid color for matched: red (want SGR parameters, e.g. 1;33)"},
+		{"users=31,blue", "invalid color for users: blue (want SGR parameters, e.g. 1;33)"},
+		{"cpu-warn-at=lots", "invalid percentage for cpu-warn-at: lots"},
+		{"sparkles=1", "unknown color key: sparkles"},
+	} {
+		err := parseColors(&tree.Theme{}, tt.spec)
+		if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("parseColors(%q) error = %v, want %q", tt.spec, err, tt.wantErr)
+		}
+	}
+}
+
+func TestColorTheme(t *testing.T) {
+	t.Setenv("PROKTREE_COLORS", "matched=35")
+
+	theme, err := colorTheme(CLI{Color: "always", Theme: "minimal"})
+	if err != nil {
+		t.Fatalf("colorTheme() error: %v", err)
+	}
+	if theme == nil || theme.Matched != "35" || theme.Glyphs != "2" {
+		t.Errorf("colorTheme() = %+v, want minimal theme with matched=35", theme)
+	}
+
+	// --colors takes precedence over the environment
+	theme, _ = colorTheme(CLI{Color: "always", Colors: "matched=36"})
+	if theme == nil || theme.Matched != "36" {
+		t.Errorf("colorTheme() = %+v, want matched=36", theme)
+	}
+
+	// Invalid colors are reported even when color is off
+	if _, err := colorTheme(CLI{Color: "never", Colors: "bogus"}); err == nil {
+		t.Errorf("colorTheme() with invalid colors succeeded, want error")
+	}
+	if theme, _ := colorTheme(CLI{Color: "never"}); theme != nil {
+		t.Errorf("colorTheme() with color off = %+v, want nil", theme)
+	}
+}
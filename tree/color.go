@@ -0,0 +1,93 @@
+package tree
+
+import (
+	"hash/fnv"
+	"strings"
+)
+
+// Theme colors rendered lines with ANSI escape sequences. Colors are SGR
+// parameters, such as "1;33" for bold yellow; an empty color leaves text as is.
+type Theme struct {
+	Header  string // Header and separator lines
+	Glyphs  string // Tree graphics
+	Matched string // Commands of processes matching the filter
+	CPU     Thresholds
+	Mem     Thresholds
+	Users   []string          // Palette for usernames, picked by a hash of the name
+	User    map[string]string // Colors for specific usernames, overriding Users
+}
+
+// Thresholds colors a percentage at or above Warn, or at or above High
+type Thresholds struct {
+	Warn      float64
+	High      float64
+	WarnColor string
+	HighColor string
+}
+
+// colorReset ends any color
+const colorReset = "\033[0m"
+
+// Colorize wraps text in the escape sequences for color
+func Colorize(color, text string) string {
+	if color == "" || text == "" {
+		return text
+	}
+	return "\033[" + color + "m" + text + colorReset
+}
+
+// color returns the color for a percentage
+func (th Thresholds) color(pct float64) string {
+	if th.HighColor != "" && pct >= th.High {
+		return th.HighColor
+	}
+	if th.WarnColor != "" && pct >= th.Warn {
+		return th.WarnColor
+	}
+	return ""
+}
+
+// userColor returns the color for a username
+func (th *Theme) userColor(user string) string {
+	if color, ok := th.User[user]; ok {
+		return color
+	}
+	if len(th.Users) == 0 {
+		return ""
+	}
+	h := fnv.New32a()
+	h.Write([]byte(user))
+	return th.Users[h.Sum32()%uint32(len(th.Users))]
+}
+
+// escapeEnd returns the index just past the ANSI escape sequence starting at
+// s[i], or i if there is none there
+func escapeEnd(s string, i int) int {
+	if i+1 >= len(s) || s[i] != '\033' || s[i+1] != '[' {
+		return i
+	}
+	for j := i + 2; j < len(s); j++ {
+		// Parameters and intermediates are 0x20-0x3f; a final byte ends the sequence
+		if s[j] >= 0x40 && s[j] <= 0x7e {
+			return j + 1
+		}
+	}
+	return len(s)
+}
+
+// StripANSI removes ANSI escape sequences from s
+func StripANSI(s string) string {
+	if !strings.Contains(s, "\033[") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if end := escapeEnd(s, i); end > i {
+			i = end
+			continue
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String()
+}
@@ -0,0 +1,101 @@
+package tree
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStripANSI(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"plain", "plain"},
+		{"\033[1;33mmatched\033[0m", "matched"},
+		{"a\033[2m│ └─\033[0mb", "a│ └─b"},
+		{"\033[", ""},
+		{"unterminated \033[1;3", "unterminated "},
+	}
+
+	for _, tt := range tests {
+		if got := StripANSI(tt.input); got != tt.expected {
+			t.Errorf("StripANSI(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+
+	if got := Colorize("", "text"); got != "text" {
+		t.Errorf("Colorize with no color = %q", got)
+	}
+	if got := Colorize("31", "text"); got != "\033[31mtext\033[0m" {
+		t.Errorf("Colorize(31) = %q", got)
+	}
+}
+
+func TestThemeRender(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, User: "root", CPUPct: 0.5, MemPct: 0.1, RSSKB: 1024.0, Command: "init"},
+		{PID: 10, PPID: 1, User: "alice", CPUPct: 60.0, MemPct: 12.0, RSSKB: 1024.0, Command: "make"},
+		{PID: 20, PPID: 10, User: "bob", CPUPct: 95.0, MemPct: 30.0, RSSKB: 1024.0, Command: "cc"},
+	}
+	theme := &Theme{
+		Header:  "1",
+		Glyphs:  "2",
+		Matched: "33",
+		CPU:     Thresholds{Warn: 50, High: 90, WarnColor: "35", HighColor: "31"},
+		Mem:     Thresholds{Warn: 10, High: 25, WarnColor: "35", HighColor: "31"},
+		User:    map[string]string{"alice": "36"},
+	}
+	filtered := New(processes).Filter(Filter{Users: []string{"alice"}})
+
+	var plain, colored strings.Builder
+	if err := (&Renderer{Indent: 2}).Render(&plain, filtered); err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	if err := (&Renderer{Indent: 2, Theme: theme}).Render(&colored, filtered); err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+
+	// Colors only add escape sequences
+	if got := StripANSI(colored.String()); got != plain.String() {
+		t.Errorf("colored output without escapes differs:\ngot:      %q\nexpected: %q", got, plain.String())
+	}
+
+	lines := strings.Split(colored.String(), "\n")
+	for _, tt := range []struct {
+		line     int
+		expected string
+	}{
+		{0, "\033[1m   PID"},
+		{1, "\033[1m-----"},
+		{2, "  0.5   0.1 "},                              // Below thresholds
+		{2, "\033[2m─┬─\033[0m init"},                    // Not matched
+		{3, "\033[36malice     \033[0m"},                 // Fixed user color
+		{3, "\033[35m 60.0\033[0m \033[35m 12.0\033[0m"}, // Warnings
+		{3, "\033[2m└─┬─\033[0m \033[33mmake\033[0m"},    // Matched
+		{4, "\033[31m 95.0\033[0m \033[31m 30.0\033[0m"}, // High
+		{4, "bob        "},                               // No palette, no color
+	} {
+		if !strings.Contains(lines[tt.line], tt.expected) {
+			t.Errorf("Line %d missing %q:\n%q", tt.line, tt.expected, lines[tt.line])
+		}
+	}
+}
+
+func TestTruncateColored(t *testing.T) {
+	r := &Renderer{Width: 10}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"\033[2m|-\033[0m short", "\033[2m|-\033[0m short"},
+		{"\033[2m|-\033[0m a long command", "\033[2m|-\033[0m a lo...\033[0m"},
+		{"plain but too long", "plain b..."},
+	}
+
+	for _, tt := range tests {
+		if got := r.truncate(tt.input); got != tt.expected {
+			t.Errorf("truncate(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package tree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// ReadEnv fills in the Env of each process from /proc, returning the number
+// of processes whose environment couldn't be read for lack of permission.
+// Processes that exited since they were listed aren't counted.
+func ReadEnv(processes []Process) (int, error) {
+	unreadable := 0
+	for i := range processes {
+		data, err := os.ReadFile(fmt.Sprintf("/proc/%d/environ", processes[i].PID))
+		if err != nil {
+			if errors.Is(err, fs.ErrPermission) {
+				unreadable++
+			}
+			continue
+		}
+		processes[i].Env = parseEnviron(data)
+	}
+	return unreadable, nil
+}
+
+// parseEnviron parses NUL-separated KEY=VALUE pairs; the first of repeated keys wins, as with getenv
+func parseEnviron(data []byte) map[string]string {
+	env := make(map[string]string)
+	for _, entry := range bytes.Split(data, []byte{0}) {
+		key, value, ok := strings.Cut(string(entry), "=")
+		if !ok || key == "" {
+			continue
+		}
+		if _, seen := env[key]; !seen {
+			env[key] = value
+		}
+	}
+	return env
+}
@@ -0,0 +1,73 @@
+package tree
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func TestParseEnviron(t *testing.T) {
+	env := parseEnviron([]byte("JOB_ID=42\x00EMPTY=\x00EQ=a=b\x00JOB_ID=shadowed\x00junk\x00=nokey\x00"))
+	expected := map[string]string{"JOB_ID": "42", "EMPTY": "", "EQ": "a=b"}
+	if len(env) != len(expected) {
+		t.Errorf("parseEnviron() = %v, want %v", env, expected)
+	}
+	for key, value := range expected {
+		if got, ok := env[key]; !ok || got != value {
+			t.Errorf("parseEnviron()[%s] = %q, want %q", key, got, value)
+		}
+	}
+}
+
+func TestReadEnv(t *testing.T) {
+	cmd := exec.Command("sleep", "60")
+	cmd.Env = []string{"JOB_ID=1234", "DEPLOY_SHA=abc123"}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("can't run sleep: %v", err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	processes := []Process{{PID: cmd.Process.Pid, Command: "sleep 60"}, {PID: 1 << 30, Command: "gone"}}
+	unreadable, err := ReadEnv(processes)
+	if err != nil {
+		t.Fatalf("ReadEnv() error: %v", err)
+	}
+	// An exited process isn't unreadable, just gone
+	if unreadable != 0 || processes[1].Env != nil {
+		t.Errorf("ReadEnv() unreadable = %d, want 0", unreadable)
+	}
+	if got := processes[0].Env["JOB_ID"]; got != "1234" {
+		t.Errorf("Env[JOB_ID] = %q, want 1234", got)
+	}
+
+	tests := []struct {
+		env     string
+		matched bool
+	}{
+		{"JOB_ID", true},
+		{"JOB_ID=1234", true},
+		{"JOB_ID=123", false},
+		{"DEPLOY_SHA=abc123", true},
+		{"HOME", false},
+	}
+	for _, tt := range tests {
+		filtered := New(processes).Filter(Filter{Env: []string{tt.env}})
+		if got := filtered.Matched(cmd.Process.Pid); got != tt.matched {
+			t.Errorf("Filter(Env: %s) matched = %v, want %v", tt.env, got, tt.matched)
+		}
+	}
+}
+
+func TestReadEnvDenied(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can read every environment")
+	}
+	processes := []Process{{PID: 1, Command: "init"}}
+	unreadable, err := ReadEnv(processes)
+	if err != nil || unreadable != 1 {
+		t.Errorf("ReadEnv() of init = %d, %v, want 1, nil", unreadable, err)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package tree
+
+import "errors"
+
+// ReadEnv fills in the Env of each process; only Linux is supported
+func ReadEnv(processes []Process) (int, error) {
+	return 0, errors.New("environments are only available on Linux")
+}
@@ -0,0 +1,60 @@
+package tree
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// ReadFiles fills in the Files of each process from /proc, returning the
+// number of processes whose open files couldn't be read, usually for lack of
+// permission
+func ReadFiles(processes []Process) (int, error) {
+	unreadable := 0
+	for i := range processes {
+		files, err := openFiles(processes[i].PID)
+		if err != nil {
+			unreadable++
+			continue
+		}
+		processes[i].Files = files
+	}
+	return unreadable, nil
+}
+
+// openFiles returns the files pid has open, by descriptor
+func openFiles(pid int) ([]File, error) {
+	return readFDDir(fmt.Sprintf("/proc/%d/fd", pid))
+}
+
+// readFDDir returns the files linked from a /proc fd directory, by descriptor
+func readFDDir(dir string) ([]File, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []File{}
+	for _, entry := range entries {
+		fd, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		// Descriptors closed since the directory was read are gone
+		path, err := os.Readlink(filepath.Join(dir, entry.Name()))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			// Listing the descriptors doesn't mean their links can be read
+			return nil, err
+		}
+		files = append(files, File{FD: fd, Path: path})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].FD < files[j].FD })
+	return files, nil
+}
@@ -0,0 +1,73 @@
+package tree
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	// Still open, as a log held by a process after rotation
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+
+	processes := []Process{{PID: os.Getpid(), PPID: 0, Command: "test"}, {PID: 1 << 30, PPID: 0, Command: "gone"}}
+	unreadable, err := ReadFiles(processes)
+	if err != nil {
+		t.Fatalf("ReadFiles() error: %v", err)
+	}
+	if unreadable != 1 || processes[1].Files != nil {
+		t.Errorf("ReadFiles() unreadable = %d, want 1", unreadable)
+	}
+
+	found := false
+	for _, file := range processes[0].Files {
+		if file.FD == int(f.Fd()) && file.Path == path+" (deleted)" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("deleted file %s not found in %+v", path, processes[0].Files)
+	}
+
+	// The deleted file is found by its old path
+	filtered := New(processes).Filter(Filter{Files: []string{path}})
+	if !filtered.Matched(os.Getpid()) || filtered.Matched(1<<30) {
+		t.Errorf("Filter(Files) matched %v", filtered.PIDs())
+	}
+}
+
+func TestReadFDDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Symlink("/var/log/app.log", filepath.Join(dir, "3")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("socket:[1234]", filepath.Join(dir, "10")); err != nil {
+		t.Fatal(err)
+	}
+	files, err := readFDDir(dir)
+	if err != nil {
+		t.Fatalf("readFDDir() error: %v", err)
+	}
+	expected := []File{{FD: 3, Path: "/var/log/app.log"}, {FD: 10, Path: "socket:[1234]"}}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("readFDDir() = %+v, want %+v", files, expected)
+	}
+
+	// A link that can't be read makes the whole process unreadable, rather
+	// than missing a descriptor
+	if err := os.WriteFile(filepath.Join(dir, "4"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if files, err := readFDDir(dir); err == nil {
+		t.Errorf("readFDDir() = %+v, want error for an unreadable link", files)
+	}
+}
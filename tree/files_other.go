@@ -0,0 +1,10 @@
+//go:build !linux
+
+package tree
+
+import "errors"
+
+// ReadFiles fills in the Files of each process; only Linux is supported
+func ReadFiles(processes []Process) (int, error) {
+	return 0, errors.New("open files are only available on Linux")
+}
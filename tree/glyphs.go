@@ -0,0 +1,41 @@
+package tree
+
+import "strings"
+
+// Glyphs are the characters drawing the tree. Each must be a single column
+// wide, so that every glyph set lines up the same way.
+type Glyphs struct {
+	Horizontal string // Joins a process to its branch, e.g. "─"
+	Vertical   string // Continues a branch past a process's children, e.g. "│"
+	Tee        string // Branches to a child with later siblings, e.g. "├"
+	Corner     string // Branches to the last child, e.g. "└"
+	Down       string // Marks a process with children, e.g. "┬"
+}
+
+// Glyph sets
+var (
+	UnicodeGlyphs = Glyphs{Horizontal: "─", Vertical: "│", Tee: "├", Corner: "└", Down: "┬"}
+	ASCIIGlyphs   = Glyphs{Horizontal: "-", Vertical: "|", Tee: "+", Corner: "`", Down: "+"}
+	RoundedGlyphs = Glyphs{Horizontal: "─", Vertical: "│", Tee: "├", Corner: "╰", Down: "┬"}
+	HeavyGlyphs   = Glyphs{Horizontal: "━", Vertical: "┃", Tee: "┣", Corner: "┗", Down: "┳"}
+)
+
+// branch returns the graphics joining n to its parent, after the prefix:
+// indent+1 columns for roots, and indent*2 columns for other processes
+func (g *Glyphs) branch(n Node, indent int) string {
+	if n.Depth == 0 {
+		if n.HasChildren {
+			return g.Horizontal + g.Down + strings.Repeat(g.Horizontal, indent-1)
+		}
+		return strings.Repeat(g.Horizontal, indent+1)
+	}
+
+	joint := g.Tee
+	if n.Last {
+		joint = g.Corner
+	}
+	if n.HasChildren {
+		return joint + strings.Repeat(g.Horizontal, indent-1) + g.Down + strings.Repeat(g.Horizontal, indent-1)
+	}
+	return joint + strings.Repeat(g.Horizontal, indent*2-1)
+}
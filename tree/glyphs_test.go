@@ -0,0 +1,70 @@
+package tree
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGlyphSets(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, User: "root", RSSKB: 1024.0, Command: "init"},
+		{PID: 10, PPID: 1, User: "user", RSSKB: 1024.0, Command: "parent"},
+		{PID: 20, PPID: 10, User: "user", RSSKB: 1024.0, Command: "child1"},
+		{PID: 30, PPID: 20, User: "user", RSSKB: 1024.0, Command: "grandchild"},
+		{PID: 21, PPID: 10, User: "user", RSSKB: 1024.0, Command: "child2"},
+		{PID: 40, PPID: 0, User: "root", RSSKB: 1024.0, Command: "lonely"},
+	}
+
+	// ASCII output, in full
+	var ascii strings.Builder
+	if err := (&Renderer{Indent: 2, Glyphs: &ASCIIGlyphs}).Render(&ascii, New(processes)); err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	expected := []string{
+		"   PID     USER     %CPU  %MEM   RSS   START    TIME    COMMAND",
+		"--------------------------------------------------------------------------------",
+		"      1 root         0.0   0.0   1.0M  --           --  -+- init",
+		"     10 user         0.0   0.0   1.0M  --           --   `-+- parent",
+		"     20 user         0.0   0.0   1.0M  --           --     +-+- child1",
+		"     30 user         0.0   0.0   1.0M  --           --     | `--- grandchild",
+		"     21 user         0.0   0.0   1.0M  --           --     `--- child2",
+		"     40 root         0.0   0.0   1.0M  --           --  --- lonely",
+	}
+	lines := strings.Split(strings.TrimRight(ascii.String(), "\n"), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines, got %d:\n%s", len(expected), len(lines), ascii.String())
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("Line %d mismatch:\ngot:      %q\nexpected: %q", i, lines[i], expected[i])
+		}
+	}
+
+	// Every set lines up exactly like the Unicode set, at every indent: same
+	// width, with graphics and spaces in the same columns
+	sets := map[string]*Glyphs{"ascii": &ASCIIGlyphs, "rounded": &RoundedGlyphs, "heavy": &HeavyGlyphs}
+	for indent := 1; indent <= 4; indent++ {
+		unicode := (&Renderer{Indent: indent}).Lines(New(processes))
+
+		for name, glyphs := range sets {
+			for i, line := range (&Renderer{Indent: indent, Glyphs: glyphs}).Lines(New(processes)) {
+				if shape(line.Branch) != shape(unicode[i].Branch) {
+					t.Errorf("%s glyphs, indent %d, line %d: %q doesn't line up with %q",
+						name, indent, i, line.Branch, unicode[i].Branch)
+				}
+			}
+		}
+	}
+}
+
+// shape replaces every non-space character of s with "x"
+func shape(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if c != ' ' {
+			c = 'x'
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
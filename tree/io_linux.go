@@ -0,0 +1,58 @@
+package tree
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ReadIO fills in the IO of each process from /proc, returning the number of
+// processes whose I/O couldn't be read, usually for lack of permission
+func ReadIO(processes []Process) (int, error) {
+	unreadable := 0
+	for i := range processes {
+		f, err := os.Open(fmt.Sprintf("/proc/%d/io", processes[i].PID))
+		if err != nil {
+			unreadable++
+			continue
+		}
+		stats, err := parseIO(f)
+		f.Close()
+		if err != nil {
+			// Read errors, e.g. EACCES, surface only once reading starts
+			unreadable++
+			continue
+		}
+		processes[i].IO = stats
+	}
+	return unreadable, nil
+}
+
+// parseIO parses /proc/<pid>/io, e.g. "read_bytes: 4096"
+func parseIO(r io.Reader) (*IO, error) {
+	stats := &IO{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %s", key, strings.TrimSpace(value))
+		}
+		switch key {
+		case "read_bytes":
+			stats.ReadBytes = n
+		case "write_bytes":
+			stats.WriteBytes = n
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,43 @@
+package tree
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseIO(t *testing.T) {
+	input := `rchar: 3980
+wchar: 120
+syscr: 9
+syscw: 2
+read_bytes: 40960
+write_bytes: 8192
+cancelled_write_bytes: 4096
+`
+	got, err := parseIO(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseIO() error: %v", err)
+	}
+	if expected := (IO{ReadBytes: 40960, WriteBytes: 8192}); *got != expected {
+		t.Errorf("parseIO() = %+v, want %+v", *got, expected)
+	}
+
+	if _, err := parseIO(strings.NewReader("read_bytes: lots\n")); err == nil {
+		t.Errorf("parseIO() of an invalid count succeeded")
+	}
+}
+
+func TestReadIO(t *testing.T) {
+	processes := []Process{{PID: os.Getpid()}, {PID: 1 << 30}}
+	unreadable, err := ReadIO(processes)
+	if err != nil || unreadable != 1 {
+		t.Fatalf("ReadIO() = %d, %v, want 1, nil", unreadable, err)
+	}
+	if processes[0].IO == nil {
+		t.Errorf("ReadIO() didn't read our own I/O")
+	}
+	if processes[1].IO != nil {
+		t.Errorf("ReadIO() of an exited process = %+v", processes[1].IO)
+	}
+}
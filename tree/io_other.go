@@ -0,0 +1,10 @@
+//go:build !linux
+
+package tree
+
+import "errors"
+
+// ReadIO fills in the IO of each process; only Linux is supported
+func ReadIO(processes []Process) (int, error) {
+	return 0, errors.New("I/O statistics are only available on Linux")
+}
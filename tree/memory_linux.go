@@ -0,0 +1,67 @@
+package tree
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ReadMemory fills in the Memory of each process from /proc, returning the
+// number of processes whose memory couldn't be read, usually for lack of
+// permission. Kernels before 4.14 lack smaps_rollup, and have its sums read
+// from smaps instead.
+func ReadMemory(processes []Process) (int, error) {
+	unreadable := 0
+	for i := range processes {
+		f, err := os.Open(fmt.Sprintf("/proc/%d/smaps_rollup", processes[i].PID))
+		if os.IsNotExist(err) {
+			f, err = os.Open(fmt.Sprintf("/proc/%d/smaps", processes[i].PID))
+		}
+		if err != nil {
+			unreadable++
+			continue
+		}
+		memory, err := parseSmaps(f)
+		f.Close()
+		if err != nil {
+			// Read errors, e.g. EPERM, surface only once reading starts
+			unreadable++
+			continue
+		}
+		processes[i].Memory = memory
+	}
+	return unreadable, nil
+}
+
+// parseSmaps sums the memory of the mappings of /proc/<pid>/smaps, or of the
+// single rollup of smaps_rollup
+func parseSmaps(r io.Reader) (*Memory, error) {
+	memory := &Memory{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// e.g. "Private_Dirty:       123 kB"; mapping headers don't end in kB
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 || fields[2] != "kB" {
+			continue
+		}
+		kb, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "Pss:":
+			memory.PSSKB += kb
+		case "Private_Clean:", "Private_Dirty:", "Private_Hugetlb:":
+			memory.USSKB += kb
+		case "Swap:":
+			memory.SwapKB += kb
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return memory, nil
+}
@@ -0,0 +1,81 @@
+package tree
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseSmaps(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Memory
+	}{
+		{
+			name: "smaps_rollup",
+			input: `5633fcbd1000-7ffc88d1c000 ---p 00000000 00:00 0                          [rollup]
+Rss:                1432 kB
+Pss:                 417 kB
+Pss_Anon:            100 kB
+Shared_Clean:       1292 kB
+Private_Clean:        40 kB
+Private_Dirty:       100 kB
+Private_Hugetlb:       0 kB
+Swap:                 12 kB
+SwapPss:               6 kB
+`,
+			expected: Memory{PSSKB: 417, USSKB: 140, SwapKB: 12},
+		},
+		{
+			name: "smaps mappings are summed",
+			input: `55d5d9e1c000-55d5d9e1e000 r--p 00000000 08:01 131090                     /usr/bin/cat
+Size:                  8 kB
+KernelPageSize:        4 kB
+Rss:                   8 kB
+Pss:                   2 kB
+Private_Clean:         0 kB
+Private_Dirty:         0 kB
+Swap:                  0 kB
+THPeligible:    0
+VmFlags: rd mr mw me sd
+7f1c2c000000-7f1c2c021000 rw-p 00000000 00:00 0
+Size:                132 kB
+Rss:                  20 kB
+Pss:                  20 kB
+Private_Clean:         4 kB
+Private_Dirty:        16 kB
+Swap:                  8 kB
+VmFlags: rd wr mr mw me nr sd
+`,
+			expected: Memory{PSSKB: 22, USSKB: 20, SwapKB: 8},
+		},
+		{name: "kernel thread", input: "", expected: Memory{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSmaps(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("parseSmaps() error: %v", err)
+			}
+			if *got != tt.expected {
+				t.Errorf("parseSmaps() = %+v, want %+v", *got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReadMemory(t *testing.T) {
+	processes := []Process{{PID: os.Getpid()}, {PID: 1 << 30}}
+	unreadable, err := ReadMemory(processes)
+	if err != nil || unreadable != 1 {
+		t.Fatalf("ReadMemory() = %d, %v, want 1, nil", unreadable, err)
+	}
+	if m := processes[0].Memory; m == nil || m.PSSKB <= 0 || m.USSKB <= 0 || m.USSKB > m.PSSKB {
+		t.Errorf("ReadMemory() of ourselves = %+v", m)
+	}
+	if processes[1].Memory != nil {
+		t.Errorf("ReadMemory() of an exited process = %+v", processes[1].Memory)
+	}
+}
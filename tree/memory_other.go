@@ -0,0 +1,10 @@
+//go:build !linux
+
+package tree
+
+import "errors"
+
+// ReadMemory fills in the Memory of each process; only Linux is supported
+func ReadMemory(processes []Process) (int, error) {
+	return 0, errors.New("proportional memory is only available on Linux")
+}
@@ -0,0 +1,32 @@
+package tree
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ReadPaths fills in the Exe, Cwd and Root of each process from /proc,
+// returning the number of processes whose paths couldn't be read, usually for
+// lack of permission. Kernel threads have none, and aren't counted.
+func ReadPaths(processes []Process) (int, error) {
+	unreadable := 0
+	for i := range processes {
+		p := &processes[i]
+		denied := false
+		for _, path := range []struct {
+			name   string
+			target *string
+		}{{"exe", &p.Exe}, {"cwd", &p.Cwd}, {"root", &p.Root}} {
+			link, err := os.Readlink(fmt.Sprintf("/proc/%d/%s", p.PID, path.name))
+			if errors.Is(err, os.ErrPermission) {
+				denied = true
+			}
+			*path.target = link
+		}
+		if denied {
+			unreadable++
+		}
+	}
+	return unreadable, nil
+}
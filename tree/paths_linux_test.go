@@ -0,0 +1,68 @@
+package tree
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadPaths(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exe, _ = filepath.EvalSymlinks(exe)
+	cwd, _ := os.Getwd()
+
+	processes := []Process{{PID: os.Getpid()}, {PID: 1 << 30}}
+	if _, err := ReadPaths(processes); err != nil {
+		t.Fatalf("ReadPaths() error: %v", err)
+	}
+	p := processes[0]
+	if p.Exe != exe || p.Cwd != cwd || p.Root != "/" {
+		t.Errorf("ReadPaths() = %q, %q, %q, want %q, %q, /", p.Exe, p.Cwd, p.Root, exe, cwd)
+	}
+	if p.ExeDeleted() {
+		t.Errorf("ExeDeleted() = true for a test binary still on disk")
+	}
+	if gone := processes[1]; gone.Exe != "" || gone.Cwd != "" || gone.Root != "" {
+		t.Errorf("ReadPaths() of an exited process = %+v", gone)
+	}
+}
+
+func TestReadPathsDeletedExe(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("no sleep to run")
+	}
+	data, err := os.ReadFile(sleep)
+	if err != nil {
+		t.Skipf("can't copy sleep: %v", err)
+	}
+
+	// Run a copy of sleep, then replace it as a deploy would
+	copied := filepath.Join(t.TempDir(), "sleep")
+	if err := os.WriteFile(copied, data, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(copied, "60")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("can't run a copy of sleep: %v", err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+	if err := os.Remove(copied); err != nil {
+		t.Fatal(err)
+	}
+
+	processes := []Process{{PID: cmd.Process.Pid}}
+	if _, err := ReadPaths(processes); err != nil {
+		t.Fatalf("ReadPaths() error: %v", err)
+	}
+	if !processes[0].ExeDeleted() || processes[0].Exe != copied+" (deleted)" {
+		t.Errorf("Exe = %q, want %q marked deleted", processes[0].Exe, copied)
+	}
+}
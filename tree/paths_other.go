@@ -0,0 +1,37 @@
+//go:build !linux
+
+package tree
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ReadPaths fills in the Exe of each process from ps, which knows the path the
+// executable was started from. Cwd and Root aren't available.
+func ReadPaths(processes []Process) (int, error) {
+	output, err := exec.Command("ps", "-axo", "pid=,comm=").Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to run ps: %v", err)
+	}
+
+	exes := make(map[int]string)
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		pidStr, comm, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		if !ok {
+			continue
+		}
+		if pid, err := strconv.Atoi(pidStr); err == nil {
+			exes[pid] = strings.TrimSpace(comm)
+		}
+	}
+
+	for i := range processes {
+		processes[i].Exe = exes[processes[i].PID]
+	}
+	return 0, nil
+}
@@ -1,4 +1,4 @@
-package main
+package tree
 
 import (
 	"bufio"
@@ -18,9 +18,67 @@ type Process struct {
 	CPUPct    float64
 	MemPct    float64
 	RSSKB     float64
+	Nice      int
 	StartTime *time.Time // nil if unknown
 	CPUTime   time.Duration
 	Command   string
+	Exe       string            // Executable, read by ReadPaths, e.g. "/usr/bin/app (deleted)" if replaced; "" if unknown
+	Cwd       string            // Working directory, read by ReadPaths; "" if unknown
+	Root      string            // Root directory, "/" unless chrooted, read by ReadPaths; "" if unknown
+	Env       map[string]string // Environment, read by ReadEnv; nil if not read or unreadable
+	Memory    *Memory           // Proportional memory, read by ReadMemory; nil if not read or unreadable
+	IO        *IO               // Storage I/O, read by ReadIO; nil if not read or unreadable
+	Session   *Session          // Job control, read by ReadSessions; nil if not read or unreadable
+	Files     []File            // Open files, read by ReadFiles; nil if not read or unreadable
+	Sockets   []Socket          // Open sockets, read by ReadSockets; nil if not read or unreadable
+}
+
+// ExeDeleted reports whether the executable of p was deleted or replaced
+// since p started, as by a deploy
+func (p *Process) ExeDeleted() bool {
+	return strings.HasSuffix(p.Exe, " (deleted)")
+}
+
+// Memory is the memory of a process, accounting for pages shared with others
+type Memory struct {
+	PSSKB  float64 // Proportional set size: private pages, plus shared pages divided among their sharers
+	USSKB  float64 // Unique set size: private pages, freed if the process exits
+	SwapKB float64 // Swapped out
+}
+
+// IO is the storage I/O of a process, as opposed to reads and writes served
+// by the page cache
+type IO struct {
+	ReadBytes  float64 // Read from storage since the process started
+	WriteBytes float64 // Written to storage since the process started
+	ReadRate   float64 // Bytes read per second over a sample; 0 if not sampled
+	WriteRate  float64 // Bytes written per second over a sample; 0 if not sampled
+}
+
+// Session is the job control membership of a process
+type Session struct {
+	SID  int    // Session ID, the PID of the session leader, e.g. a login shell
+	PGID int    // Process group ID, the PID of the group leader, e.g. the first command of a pipeline
+	TTY  string // Controlling terminal, e.g. "pts/3"; "" if none
+}
+
+// Leader reports whether p leads its session
+func (p *Process) Leader() bool {
+	return p.Session != nil && p.Session.SID == p.PID
+}
+
+// File is an open file descriptor of a process
+type File struct {
+	FD   int
+	Path string // What the descriptor refers to, e.g. "/var/log/app.log (deleted)" or "pipe:[5678]"
+}
+
+// Socket is an open socket of a process
+type Socket struct {
+	Proto  string // "tcp", "tcp6", "udp", "udp6" or "unix"
+	Local  string // Local address, e.g. "127.0.0.1:8080", or the path of a unix socket
+	Port   int    // Local port; 0 for unix sockets
+	Listen bool   // TCP listening, UDP unconnected, or unix accepting connections
 }
 
 // Platform-specific operations
@@ -45,7 +103,7 @@ type Darwin struct{}
 
 func (d *Darwin) GetProcesses() ([]Process, error) {
 	// Get process info including PPID with macOS-specific lstart
-	cmd := exec.Command("ps", "-axo", "pid,ppid,user,pcpu,pmem,rss,lstart,time,command")
+	cmd := exec.Command("ps", "-axo", "pid,ppid,user,pcpu,pmem,rss,nice,lstart,time,command")
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("failed to run ps: %v", err)
@@ -59,7 +117,7 @@ func (d *Darwin) GetProcesses() ([]Process, error) {
 		line := scanner.Text()
 		fields := strings.Fields(line)
 
-		if len(fields) < 9 {
+		if len(fields) < 10 {
 			continue
 		}
 
@@ -69,6 +127,7 @@ func (d *Darwin) GetProcesses() ([]Process, error) {
 		cpuPct, _ := strconv.ParseFloat(fields[3], 64)
 		memPct, _ := strconv.ParseFloat(fields[4], 64)
 		rssKb, _ := strconv.ParseFloat(fields[5], 64)
+		nice, _ := strconv.Atoi(fields[6])
 
 		// Parse lstart and time
 		// lstart format: "Thu Jul 10 15:37:36 2025" (6 fields)
@@ -78,19 +137,19 @@ func (d *Darwin) GetProcesses() ([]Process, error) {
 		var cmd string
 
 		// Find where TIME field starts (after year in lstart)
-		// lstart is 5 fields starting at field 6 (Thu Jul 10 15:37:36 2025)
-		if len(fields) >= 12 {
-			// Standard format: fields 6-10 are lstart (Thu Jul 10 15:37:36 2025)
-			// field 11 is TIME
-			// field 12+ is COMMAND
-			startRaw = strings.Join(fields[6:11], " ") // Include the year
-			timeStr = fields[11]
-			cmd = strings.Join(fields[12:], " ")
+		// lstart is 5 fields starting at field 7 (Thu Jul 10 15:37:36 2025)
+		if len(fields) >= 13 {
+			// Standard format: fields 7-11 are lstart (Thu Jul 10 15:37:36 2025)
+			// field 12 is TIME
+			// field 13+ is COMMAND
+			startRaw = strings.Join(fields[7:12], " ") // Include the year
+			timeStr = fields[12]
+			cmd = strings.Join(fields[13:], " ")
 		} else {
 			// Fallback for unexpected format
 			startRaw = ""
 			timeStr = "--"
-			cmd = strings.Join(fields[8:], " ")
+			cmd = strings.Join(fields[9:], " ")
 		}
 
 		// Parse start time
@@ -111,6 +170,7 @@ func (d *Darwin) GetProcesses() ([]Process, error) {
 			CPUPct:    cpuPct,
 			MemPct:    memPct,
 			RSSKB:     rssKb,
+			Nice:      nice,
 			StartTime: startTime,
 			CPUTime:   cpuTime,
 			Command:   cmd,
@@ -126,7 +186,7 @@ type Linux struct{}
 func (l *Linux) GetProcesses() ([]Process, error) {
 	// Use Linux ps with -D flag to specify exact lstart format
 	// This gives us an ISO-like timestamp that's easy to parse
-	cmd := exec.Command("ps", "-D", "%Y-%m-%d %H:%M:%S", "-eo", "pid,ppid,user,pcpu,pmem,rss,lstart,time,cmd")
+	cmd := exec.Command("ps", "-D", "%Y-%m-%d %H:%M:%S", "-eo", "pid,ppid,user,pcpu,pmem,rss,ni,lstart,time,cmd")
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("failed to run ps: %v", err)
@@ -140,7 +200,7 @@ func (l *Linux) GetProcesses() ([]Process, error) {
 		line := scanner.Text()
 		fields := strings.Fields(line)
 
-		if len(fields) < 10 {
+		if len(fields) < 11 {
 			continue
 		}
 
@@ -150,25 +210,25 @@ func (l *Linux) GetProcesses() ([]Process, error) {
 		cpuPct, _ := strconv.ParseFloat(fields[3], 64)
 		memPct, _ := strconv.ParseFloat(fields[4], 64)
 		rssKb, _ := strconv.ParseFloat(fields[5], 64)
+		nice, _ := strconv.Atoi(fields[6]) // "-" for real-time processes
 
 		// Parse start time from ISO-like format
 		// With -D "%Y-%m-%d %H:%M:%S", lstart is 2 fields
-		// fields[6] = date (YYYY-MM-DD)
-		// fields[7] = time (HH:MM:SS)
+		// fields[7] = date (YYYY-MM-DD)
+		// fields[8] = time (HH:MM:SS)
 		var startTime *time.Time
-		startStr := fields[6] + " " + fields[7]
-		if t, err := time.Parse("2006-01-02 15:04:05", startStr); err == nil {
+		if t, err := parseLinuxStartTime(fields[7] + " " + fields[8]); err == nil {
 			startTime = &t
 		}
 
 		// Parse CPU time (Linux format: [DD-]HH:MM:SS)
-		// fields[8] = TIME
-		timeStr := fields[8]
+		// fields[9] = TIME
+		timeStr := fields[9]
 		cpuTime := parseLinuxCPUTime(timeStr)
 
 		// Parse command
-		// fields[9+] = COMMAND
-		cmd := strings.Join(fields[9:], " ")
+		// fields[10+] = COMMAND
+		cmd := strings.Join(fields[10:], " ")
 
 		processes = append(processes, Process{
 			PID:       pid,
@@ -177,6 +237,7 @@ func (l *Linux) GetProcesses() ([]Process, error) {
 			CPUPct:    cpuPct,
 			MemPct:    memPct,
 			RSSKB:     rssKb,
+			Nice:      nice,
 			StartTime: startTime,
 			CPUTime:   cpuTime,
 			Command:   cmd,
@@ -186,7 +247,13 @@ func (l *Linux) GetProcesses() ([]Process, error) {
 	return processes, nil
 }
 
-// parseDarwinStartTime parses macOS lstart format
+// parseLinuxStartTime parses lstart as formatted by -D "%Y-%m-%d %H:%M:%S", in
+// local time like all ps times
+func parseLinuxStartTime(startRaw string) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02 15:04:05", startRaw, time.Local)
+}
+
+// parseDarwinStartTime parses macOS lstart format, in local time
 func parseDarwinStartTime(startRaw string) (time.Time, error) {
 	// Format from ps: "Thu Jul 10 15:37:36 2025"
 	formats := []string{
@@ -197,7 +264,7 @@ func parseDarwinStartTime(startRaw string) (time.Time, error) {
 	}
 
 	for _, format := range formats {
-		if t, err := time.Parse(format, startRaw); err == nil {
+		if t, err := time.ParseInLocation(format, startRaw, time.Local); err == nil {
 			return t, nil
 		}
 	}
@@ -0,0 +1,38 @@
+package tree
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseStartTime(t *testing.T) {
+	// ps prints start times in local time, not UTC
+	original := time.Local
+	time.Local = time.FixedZone("PDT", -7*3600)
+	defer func() { time.Local = original }()
+	expected := time.Date(2025, 7, 10, 22, 37, 36, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		parse func(string) (time.Time, error)
+		raw   string
+	}{
+		{name: "linux", parse: parseLinuxStartTime, raw: "2025-07-10 15:37:36"},
+		{name: "darwin", parse: parseDarwinStartTime, raw: "Thu Jul 10 15:37:36 2025"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.parse(tt.raw)
+			if err != nil {
+				t.Fatalf("parse(%q) error: %v", tt.raw, err)
+			}
+			if !got.Equal(expected) {
+				t.Errorf("parse(%q) = %v, want %v", tt.raw, got, expected)
+			}
+			if formatted := got.Format(time.RFC3339); formatted != "2025-07-10T15:37:36-07:00" {
+				t.Errorf("parse(%q) formats as %s, want 2025-07-10T15:37:36-07:00", tt.raw, formatted)
+			}
+		})
+	}
+}
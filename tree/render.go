@@ -0,0 +1,387 @@
+package tree
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// DefaultScreenWidth is the separator width when no terminal width is known
+const DefaultScreenWidth = 80
+
+// Renderer writes process trees as text: a header, then one line per visible
+// process with its columns, tree graphics and command.
+type Renderer struct {
+	Indent      int              // Spaces for each indentation level; 0 means 2
+	FullUser    bool             // Show full usernames, without truncation
+	FullCommand bool             // Show full commands, without truncation
+	Width       int              // Truncate lines to this many columns; 0 for no truncation
+	Now         func() time.Time // Reference time for START; nil means time.Now
+	Columns     []Column         // Extra columns shown after TIME
+	Theme       *Theme           // Colors, or nil for plain text
+	Glyphs      *Glyphs          // Tree graphics; nil means UnicodeGlyphs
+	Files       bool             // Show open files as leaves under each process; needs Process.Files
+
+	// Tag, if set, returns text shown before the command of a process to flag
+	// it, e.g. "[orphan]", or "" for none
+	Tag func(p *Process) string
+
+	// LineFormat, if set, formats each line in place of the default columns,
+	// and no header is written
+	LineFormat func(l Line) string
+}
+
+// Line is a visible process ready for display
+//
+// With a Theme, Branch and Content include color escape sequences.
+type Line struct {
+	Node
+	Branch  string // Tree graphics for the line, e.g. "│ └─┬─"
+	Content string // Formatted columns, without tree graphics or command
+}
+
+// Column is an extra column of the default line format
+type Column struct {
+	Header string
+	Value  func(p *Process) string
+	Left   bool // Left-align values, e.g. for text; values are right-aligned by default
+}
+
+// columnWidths are the widths of variable-width columns
+type columnWidths struct {
+	user  int
+	start int
+	time  int
+	extra []int // Widths of the Renderer's Columns
+}
+
+// now returns the current time using Now if set, otherwise time.Now
+func (r *Renderer) now() time.Time {
+	if r.Now != nil {
+		return r.Now()
+	}
+	return time.Now()
+}
+
+// glyphs returns the glyph set to draw with
+func (r *Renderer) glyphs() *Glyphs {
+	if r.Glyphs == nil {
+		return &UnicodeGlyphs
+	}
+	return r.Glyphs
+}
+
+// indent returns the indentation size
+func (r *Renderer) indent() int {
+	if r.Indent <= 0 {
+		return 2
+	}
+	return r.Indent
+}
+
+// Render writes the visible processes of t. Nothing, not even the header, is
+// written if no process is visible.
+func (r *Renderer) Render(w io.Writer, t *Tree) error {
+	lines := r.Lines(t)
+	if len(lines) == 0 {
+		return nil
+	}
+
+	bw := bufio.NewWriter(w)
+	if r.LineFormat == nil {
+		r.writeHeader(bw, r.columnWidths(t))
+	}
+
+	for _, line := range lines {
+		var fullLine string
+		if r.LineFormat != nil {
+			fullLine = r.LineFormat(line)
+		} else {
+			// Root needs 2 spaces, others need 3 for proper alignment
+			spacing := "   "
+			if line.Depth == 0 {
+				spacing = "  "
+			}
+			command := line.Process.Command
+			if r.Theme != nil && t.Matched(line.Process.PID) {
+				command = Colorize(r.Theme.Matched, command)
+			}
+			if r.Tag != nil {
+				if tag := r.Tag(line.Process); tag != "" {
+					command = tag + " " + command
+				}
+			}
+			fullLine = fmt.Sprintf("%s%s%s %s", line.Content, spacing, line.Branch, command)
+		}
+
+		fmt.Fprintln(bw, r.truncate(fullLine))
+		if r.Files && r.LineFormat == nil {
+			r.writeFiles(bw, line)
+		}
+	}
+
+	return bw.Flush()
+}
+
+// Lines returns the visible processes of t in display order, formatted for display
+func (r *Renderer) Lines(t *Tree) []Line {
+	widths := r.columnWidths(t)
+
+	// Build indentation strings based on the configured indent size
+	indent := r.indent()
+	glyphs := r.glyphs()
+	indentSpace := strings.Repeat(" ", indent)
+	indentVertical := glyphs.Vertical + strings.Repeat(" ", indent-1)
+
+	var lines []Line
+	_ = t.Walk(func(n Node) error {
+		// Build the prefix string from the prefix parts
+		var prefix strings.Builder
+		for _, hasVertical := range n.Prefix {
+			if hasVertical {
+				prefix.WriteString(indentVertical)
+			} else {
+				prefix.WriteString(indentSpace)
+			}
+		}
+
+		// Open files hang under the process, before its children
+		branchNode := n
+		if r.Files && len(n.Process.Files) > 0 {
+			branchNode.HasChildren = true
+		}
+		branch := glyphs.branch(branchNode, indent)
+
+		// Format the process info
+		p := n.Process
+		user := PadRight(r.TruncateUser(p.User), widths.user)
+		cpu := fmt.Sprintf("%5.1f", p.CPUPct)
+		mem := fmt.Sprintf("%5.1f", p.MemPct)
+		if r.Theme != nil {
+			user = Colorize(r.Theme.userColor(p.User), user)
+			cpu = Colorize(r.Theme.CPU.color(p.CPUPct), cpu)
+			mem = Colorize(r.Theme.Mem.color(p.MemPct), mem)
+			branch = Colorize(r.Theme.Glyphs, prefix.String()+branch)
+		} else {
+			branch = prefix.String() + branch
+		}
+		content := fmt.Sprintf("%7d %s %s %s %6s  %-*s  %-*s",
+			p.PID, user, cpu, mem, FormatRSS(p.RSSKB),
+			widths.start, r.FormatStartTime(p.StartTime),
+			widths.time, FormatCPUTime(p.CPUTime))
+		for i, column := range r.Columns {
+			content += "  " + alignText(column.Value(p), widths.extra[i], column.Left)
+		}
+
+		lines = append(lines, Line{
+			Node:    n,
+			Branch:  branch,
+			Content: content,
+		})
+		return nil
+	})
+
+	return lines
+}
+
+// writeFiles writes the open files of a line's process as leaves under it,
+// with the columns left blank
+func (r *Renderer) writeFiles(w io.Writer, line Line) {
+	files := line.Process.Files
+	if len(files) == 0 {
+		return
+	}
+
+	indent := r.indent()
+	glyphs := r.glyphs()
+
+	// Files are children of the process, with its children after them
+	var prefix strings.Builder
+	if line.Depth > 0 {
+		for _, hasVertical := range append(line.Prefix, !line.Last) {
+			if hasVertical {
+				prefix.WriteString(glyphs.Vertical + strings.Repeat(" ", indent-1))
+			} else {
+				prefix.WriteString(strings.Repeat(" ", indent))
+			}
+		}
+	}
+	blank := strings.Repeat(" ", DisplayWidth(line.Content))
+
+	for i, file := range files {
+		leaf := Node{Depth: line.Depth + 1, Last: i == len(files)-1 && !line.HasChildren}
+		branch := prefix.String() + glyphs.branch(leaf, indent)
+		if r.Theme != nil {
+			branch = Colorize(r.Theme.Glyphs, branch)
+		}
+		fmt.Fprintln(w, r.truncate(fmt.Sprintf("%s   %s %d: %s", blank, branch, file.FD, file.Path)))
+	}
+}
+
+// columnWidths calculates the width of variable columns over all processes
+func (r *Renderer) columnWidths(t *Tree) columnWidths {
+	widths := columnWidths{user: 10, start: 5, time: 4}
+
+	if r.FullUser {
+		// Find actual max user length when showing full names
+		for _, p := range t.processes {
+			if w := DisplayWidth(p.User); w > widths.user {
+				widths.user = w
+			}
+		}
+	}
+
+	for _, p := range t.processes {
+		startStr := r.FormatStartTime(p.StartTime)
+		if len(startStr) > widths.start {
+			widths.start = len(startStr)
+		}
+		timeStr := strings.TrimSpace(FormatCPUTime(p.CPUTime))
+		if len(timeStr) > widths.time {
+			widths.time = len(timeStr)
+		}
+	}
+
+	// Ensure minimum width for TIME column
+	if widths.time < 8 {
+		widths.time = 8
+	}
+
+	for _, column := range r.Columns {
+		width := DisplayWidth(column.Header)
+		for _, p := range t.processes {
+			if w := DisplayWidth(column.Value(p)); w > width {
+				width = w
+			}
+		}
+		widths.extra = append(widths.extra, width)
+	}
+
+	return widths
+}
+
+// writeHeader writes the column headers
+func (r *Renderer) writeHeader(w io.Writer, widths columnWidths) {
+	var extra strings.Builder
+	for i, column := range r.Columns {
+		extra.WriteString(alignText(column.Header, widths.extra[i], column.Left) + "  ")
+	}
+	header := fmt.Sprintf("  %5s %-*s %5s %5s %5s   %-*s  %-*s  %s%s",
+		centerText("PID", 5), widths.user, centerText("USER", widths.user), "%CPU", "%MEM", "RSS",
+		widths.start, "START",
+		widths.time, centerText("TIME", widths.time),
+		extra.String(), "COMMAND")
+	separator := strings.Repeat("-", r.Width)
+	if r.FullCommand || r.Width <= 0 {
+		// When showing full commands, or piped without terminal, use a fixed width separator
+		separator = strings.Repeat("-", DefaultScreenWidth)
+	}
+	if r.Theme != nil {
+		header = Colorize(r.Theme.Header, header)
+		separator = Colorize(r.Theme.Header, separator)
+	}
+	fmt.Fprintln(w, header)
+	fmt.Fprintln(w, separator)
+}
+
+// truncate shortens a line to the renderer's width in terminal columns, unless
+// showing full commands. Color escape sequences are kept, and don't count
+// toward the width.
+func (r *Renderer) truncate(line string) string {
+	if r.FullCommand || r.Width <= 3 {
+		return line
+	}
+	return TruncateWidth(line, r.Width)
+}
+
+func centerText(text string, width int) string {
+	padding := width - DisplayWidth(text)
+	if padding <= 0 {
+		return text
+	}
+	leftPad := padding / 2
+	rightPad := padding - leftPad
+	return strings.Repeat(" ", leftPad) + text + strings.Repeat(" ", rightPad)
+}
+
+// alignText pads text to width, on the right if left is set, otherwise on the left
+func alignText(text string, width int, left bool) string {
+	if left {
+		return PadRight(text, width)
+	}
+	return PadLeft(text, width)
+}
+
+// TruncateUser truncates usernames wider than 10 columns, unless FullUser is set
+func (r *Renderer) TruncateUser(user string) string {
+	if r.FullUser {
+		return user
+	}
+	return TruncateWidth(user, 10)
+}
+
+// FormatRSS formats RSS in KB to a human-readable string
+func FormatRSS(rssKB float64) string {
+	if rssKB >= 1048576 {
+		return fmt.Sprintf("%.1fG", rssKB/1048576)
+	}
+	return fmt.Sprintf("%.1fM", rssKB/1024)
+}
+
+// FormatBytes formats a byte count to a human-readable string, e.g. "12.5M"
+func FormatBytes(bytes float64) string {
+	switch {
+	case bytes >= 1<<40:
+		return fmt.Sprintf("%.1fT", bytes/(1<<40))
+	case bytes >= 1<<30:
+		return fmt.Sprintf("%.1fG", bytes/(1<<30))
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1fM", bytes/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%.1fK", bytes/(1<<10))
+	}
+	return fmt.Sprintf("%.0fB", bytes)
+}
+
+// FormatStartTime formats start time for display
+func (r *Renderer) FormatStartTime(startTime *time.Time) string {
+	if startTime == nil {
+		return "--"
+	}
+
+	now := r.now()
+	ageHours := now.Sub(*startTime).Hours()
+
+	if ageHours < 24 {
+		// Less than 24 hours ago: show HH:MM
+		return startTime.Format("15:04")
+	} else if startTime.Year() == now.Year() {
+		// Current year: show MonDD
+		return startTime.Format("Jan02")
+	} else {
+		// Previous years: show YYYY
+		return startTime.Format("2006")
+	}
+}
+
+// FormatCPUTime formats CPU time duration for display
+func FormatCPUTime(cpuTime time.Duration) string {
+	if cpuTime == 0 {
+		return "      --"
+	}
+
+	totalSeconds := int(cpuTime.Seconds())
+	hours := totalSeconds / 3600
+	minutes := (totalSeconds % 3600) / 60
+	seconds := totalSeconds % 60
+
+	if hours >= 24 {
+		// Right-justify the hours format
+		return fmt.Sprintf("%5dhrs", hours)
+	} else {
+		// HH:MM:SS format for under 24 hours
+		return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
+	}
+}
@@ -0,0 +1,864 @@
+package tree
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatStartTime(t *testing.T) {
+	// Use a fixed time for testing
+	fixedNow := time.Date(2025, 7, 16, 10, 0, 0, 0, time.UTC)
+
+	// Create a Renderer with a fixed clock
+	r := &Renderer{
+		Now: func() time.Time { return fixedNow },
+	}
+
+	tests := []struct {
+		name     string
+		input    *time.Time
+		expected string
+	}{
+		{
+			name:     "nil input",
+			input:    nil,
+			expected: "--",
+		},
+		{
+			name:     "recent time (less than 24 hours)",
+			input:    func() *time.Time { t := fixedNow.Add(-2 * time.Hour); return &t }(),
+			expected: fixedNow.Add(-2 * time.Hour).Format("15:04"),
+		},
+		{
+			name:     "current year",
+			input:    func() *time.Time { t := fixedNow.Add(-48 * time.Hour); return &t }(),
+			expected: fixedNow.Add(-48 * time.Hour).Format("Jan02"),
+		},
+		{
+			name:     "previous year",
+			input:    func() *time.Time { t := fixedNow.Add(-400 * 24 * time.Hour); return &t }(),
+			expected: fixedNow.Add(-400 * 24 * time.Hour).Format("2006"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := r.FormatStartTime(tt.input)
+			if result != tt.expected {
+				t.Errorf("FormatStartTime(%v) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatCPUTime(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    time.Duration
+		expected string
+	}{
+		{
+			name:     "zero duration",
+			input:    0,
+			expected: "      --",
+		},
+		{
+			name:     "minutes and seconds",
+			input:    1*time.Minute + 23*time.Second + 450*time.Millisecond,
+			expected: "00:01:23",
+		},
+		{
+			name:     "hours and minutes",
+			input:    12*time.Hour + 34*time.Minute + 56*time.Second,
+			expected: "12:34:56",
+		},
+		{
+			name:     "24+ hours",
+			input:    25 * time.Hour,
+			expected: "   25hrs",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := FormatCPUTime(tt.input)
+			if result != tt.expected {
+				t.Errorf("FormatCPUTime(%v) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		input    float64
+		expected string
+	}{
+		{0, "0B"},
+		{512, "512B"},
+		{1536, "1.5K"},
+		{5 << 20, "5.0M"},
+		{3 << 30, "3.0G"},
+		{2 << 40, "2.0T"},
+	}
+
+	for _, tt := range tests {
+		if result := FormatBytes(tt.input); result != tt.expected {
+			t.Errorf("FormatBytes(%v) = %q, want %q", tt.input, result, tt.expected)
+		}
+	}
+}
+
+func TestCenterText(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		width    int
+		expected string
+	}{
+		{
+			name:     "text shorter than width",
+			text:     "PID",
+			width:    5,
+			expected: " PID ",
+		},
+		{
+			name:     "text equal to width",
+			text:     "HELLO",
+			width:    5,
+			expected: "HELLO",
+		},
+		{
+			name:     "text longer than width",
+			text:     "TOOLONG",
+			width:    5,
+			expected: "TOOLONG",
+		},
+		{
+			name:     "even padding",
+			text:     "HI",
+			width:    6,
+			expected: "  HI  ",
+		},
+		{
+			name:     "odd padding",
+			text:     "HI",
+			width:    5,
+			expected: " HI  ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := centerText(tt.text, tt.width)
+			if result != tt.expected {
+				t.Errorf("centerText(%q, %d) = %q, want %q", tt.text, tt.width, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTruncateUser(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		user         string
+		showFullUser bool
+		expected     string
+	}{
+		{
+			name:         "short username",
+			user:         "root",
+			showFullUser: false,
+			expected:     "root",
+		},
+		{
+			name:         "long username truncated",
+			user:         "verylongusername",
+			showFullUser: false,
+			expected:     "verylon...",
+		},
+		{
+			name:         "long username full",
+			user:         "verylongusername",
+			showFullUser: true,
+			expected:     "verylongusername",
+		},
+		{
+			name:         "exactly 10 chars",
+			user:         "1234567890",
+			showFullUser: false,
+			expected:     "1234567890",
+		},
+		{
+			name:         "11 chars truncated",
+			user:         "12345678901",
+			showFullUser: false,
+			expected:     "1234567...",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Renderer{FullUser: tt.showFullUser}
+			result := r.TruncateUser(tt.user)
+			if result != tt.expected {
+				t.Errorf("TruncateUser(%q) = %q, want %q", tt.user, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProcessTreeOutput(t *testing.T) {
+	// Use a fixed "now" time for consistent test results
+	testNow := time.Date(2025, 7, 16, 10, 0, 0, 0, time.UTC)
+
+	// Create test processes with static times relative to testNow
+	jul10 := time.Date(2025, 7, 10, 0, 0, 0, 0, time.UTC)     // Current year -> "Jul10"
+	jun01 := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)      // Current year -> "Jun01"
+	recent1 := time.Date(2025, 7, 15, 13, 10, 0, 0, time.UTC) // Within 24h of testNow -> "13:10"
+	recent2 := time.Date(2025, 7, 15, 14, 40, 0, 0, time.UTC) // Within 24h of testNow -> "14:40"
+	lastYear := time.Date(2023, 12, 25, 0, 0, 0, 0, time.UTC) // Previous year -> "2023"
+
+	processes := []Process{
+		{
+			PID:       1,
+			PPID:      0,
+			User:      "root",
+			CPUPct:    1.5,
+			MemPct:    0.8,
+			RSSKB:     31641.6, // 30.9M
+			StartTime: &jul10,
+			CPUTime:   28*time.Minute + 35*time.Second,
+			Command:   "/sbin/launchd",
+		},
+		{
+			PID:       100,
+			PPID:      1,
+			User:      "daemon",
+			CPUPct:    0.0,
+			MemPct:    0.1,
+			RSSKB:     10240.0, // 10.0M
+			StartTime: &jul10,
+			CPUTime:   0,
+			Command:   "/usr/sbin/sshd",
+		},
+		{
+			PID:       200,
+			PPID:      100,
+			User:      "alice",
+			CPUPct:    25.3,
+			MemPct:    15.2,
+			RSSKB:     1048576.0, // 1.0G
+			StartTime: nil,
+			CPUTime:   25 * time.Hour,
+			Command:   "sshd: alice [priv]",
+		},
+		{
+			PID:       201,
+			PPID:      200,
+			User:      "alice",
+			CPUPct:    0.1,
+			MemPct:    0.5,
+			RSSKB:     5120.0, // 5.0M
+			StartTime: &recent1,
+			CPUTime:   2*time.Minute + 15*time.Second,
+			Command:   "-bash",
+		},
+		{
+			PID:       300,
+			PPID:      1,
+			User:      "postgres",
+			CPUPct:    5.2,
+			MemPct:    12.3,
+			RSSKB:     524288.0, // 512M
+			StartTime: &jun01,
+			CPUTime:   125 * time.Hour,
+			Command:   "/usr/bin/postgres -D /var/lib/postgresql",
+		},
+		{
+			PID:       301,
+			PPID:      300,
+			User:      "postgres",
+			CPUPct:    0.5,
+			MemPct:    2.1,
+			RSSKB:     102400.0, // 100M
+			StartTime: &jun01,
+			CPUTime:   45*time.Minute + 30*time.Second,
+			Command:   "postgres: writer process",
+		},
+		{
+			PID:       302,
+			PPID:      300,
+			User:      "postgres",
+			CPUPct:    0.3,
+			MemPct:    1.8,
+			RSSKB:     81920.0, // 80M
+			StartTime: &jun01,
+			CPUTime:   22 * time.Minute,
+			Command:   "postgres: checkpointer",
+		},
+		{
+			PID:       400,
+			PPID:      1,
+			User:      "bob",
+			CPUPct:    15.7,
+			MemPct:    8.9,
+			RSSKB:     204800.0, // 200M
+			StartTime: &recent2,
+			CPUTime:   5*time.Minute + 45*time.Second,
+			Command:   "node server.js",
+		},
+		{
+			PID:       500,
+			PPID:      1,
+			User:      "verylongusername",
+			CPUPct:    0.0,
+			MemPct:    0.1,
+			RSSKB:     2048.0, // 2.0M
+			StartTime: &lastYear,
+			CPUTime:   0,
+			Command:   "/usr/local/bin/custom-daemon",
+		},
+	}
+
+	tests := []struct {
+		name         string
+		filter       Filter
+		showFullUser bool
+		expected     []string // Expected output lines
+	}{
+		{
+			name:   "no filter - show all",
+			filter: Filter{},
+			expected: []string{
+				"   PID     USER     %CPU  %MEM   RSS   START    TIME    COMMAND",
+				"--------------------------------------------------------------------------------",
+				"      1 root         1.5   0.8  30.9M  Jul10  00:28:35  ─┬─ /sbin/launchd",
+				"    100 daemon       0.0   0.1  10.0M  Jul10        --   ├─┬─ /usr/sbin/sshd",
+				"    200 alice       25.3  15.2   1.0G  --        25hrs   │ └─┬─ sshd: alice [priv]",
+				"    201 alice        0.1   0.5   5.0M  13:10  00:02:15   │   └─── -bash",
+				"    300 postgres     5.2  12.3 512.0M  Jun01    125hrs   ├─┬─ /usr/bin/postgres -D /var/lib/postgresql",
+				"    301 postgres     0.5   2.1 100.0M  Jun01  00:45:30   │ ├─── postgres: writer process",
+				"    302 postgres     0.3   1.8  80.0M  Jun01  00:22:00   │ └─── postgres: checkpointer",
+				"    400 bob         15.7   8.9 200.0M  14:40  00:05:45   ├─── node server.js",
+				"    500 verylon...   0.0   0.1   2.0M  2023         --   └─── /usr/local/bin/custom-daemon",
+			},
+		},
+		{
+			name:   "filter by PID - shows ancestors and descendants",
+			filter: Filter{PIDs: []int{200}},
+			expected: []string{
+				"   PID     USER     %CPU  %MEM   RSS   START    TIME    COMMAND",
+				"--------------------------------------------------------------------------------",
+				"      1 root         1.5   0.8  30.9M  Jul10  00:28:35  ─┬─ /sbin/launchd",
+				"    100 daemon       0.0   0.1  10.0M  Jul10        --   └─┬─ /usr/sbin/sshd",
+				"    200 alice       25.3  15.2   1.0G  --        25hrs     └─┬─ sshd: alice [priv]",
+				"    201 alice        0.1   0.5   5.0M  13:10  00:02:15       └─── -bash",
+			},
+		},
+		{
+			name:   "filter by user alice",
+			filter: Filter{Users: []string{"alice"}},
+			expected: []string{
+				"   PID     USER     %CPU  %MEM   RSS   START    TIME    COMMAND",
+				"--------------------------------------------------------------------------------",
+				"      1 root         1.5   0.8  30.9M  Jul10  00:28:35  ─┬─ /sbin/launchd",
+				"    100 daemon       0.0   0.1  10.0M  Jul10        --   └─┬─ /usr/sbin/sshd",
+				"    200 alice       25.3  15.2   1.0G  --        25hrs     └─┬─ sshd: alice [priv]",
+				"    201 alice        0.1   0.5   5.0M  13:10  00:02:15       └─── -bash",
+			},
+		},
+		{
+			name:   "filter by user postgres",
+			filter: Filter{Users: []string{"postgres"}},
+			expected: []string{
+				"   PID     USER     %CPU  %MEM   RSS   START    TIME    COMMAND",
+				"--------------------------------------------------------------------------------",
+				"      1 root         1.5   0.8  30.9M  Jul10  00:28:35  ─┬─ /sbin/launchd",
+				"    300 postgres     5.2  12.3 512.0M  Jun01    125hrs   └─┬─ /usr/bin/postgres -D /var/lib/postgresql",
+				"    301 postgres     0.5   2.1 100.0M  Jun01  00:45:30     ├─── postgres: writer process",
+				"    302 postgres     0.3   1.8  80.0M  Jun01  00:22:00     └─── postgres: checkpointer",
+			},
+		},
+		{
+			name:   "filter by command postgres",
+			filter: Filter{Strings: []string{"postgres"}},
+			expected: []string{
+				"   PID     USER     %CPU  %MEM   RSS   START    TIME    COMMAND",
+				"--------------------------------------------------------------------------------",
+				"      1 root         1.5   0.8  30.9M  Jul10  00:28:35  ─┬─ /sbin/launchd",
+				"    300 postgres     5.2  12.3 512.0M  Jun01    125hrs   └─┬─ /usr/bin/postgres -D /var/lib/postgresql",
+				"    301 postgres     0.5   2.1 100.0M  Jun01  00:45:30     ├─── postgres: writer process",
+				"    302 postgres     0.3   1.8  80.0M  Jun01  00:22:00     └─── postgres: checkpointer",
+			},
+		},
+		{
+			name:   "filter by multiple users",
+			filter: Filter{Users: []string{"alice", "bob"}},
+			expected: []string{
+				"   PID     USER     %CPU  %MEM   RSS   START    TIME    COMMAND",
+				"--------------------------------------------------------------------------------",
+				"      1 root         1.5   0.8  30.9M  Jul10  00:28:35  ─┬─ /sbin/launchd",
+				"    100 daemon       0.0   0.1  10.0M  Jul10        --   ├─┬─ /usr/sbin/sshd",
+				"    200 alice       25.3  15.2   1.0G  --        25hrs   │ └─┬─ sshd: alice [priv]",
+				"    201 alice        0.1   0.5   5.0M  13:10  00:02:15   │   └─── -bash",
+				"    400 bob         15.7   8.9 200.0M  14:40  00:05:45   └─── node server.js",
+			},
+		},
+		{
+			name:         "full username display",
+			filter:       Filter{Users: []string{"verylongusername"}},
+			showFullUser: true,
+			expected: []string{
+				"   PID        USER        %CPU  %MEM   RSS   START    TIME    COMMAND",
+				"--------------------------------------------------------------------------------",
+				"      1 root               1.5   0.8  30.9M  Jul10  00:28:35  ─┬─ /sbin/launchd",
+				"    500 verylongusername   0.0   0.1   2.0M  2023         --   └─── /usr/local/bin/custom-daemon",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create a test Renderer with showFullUser setting
+			r := &Renderer{
+				Indent:   2,
+				FullUser: tt.showFullUser,
+				Width:    0,
+				Now:      func() time.Time { return testNow },
+			}
+
+			// Apply filters using the actual filtering logic
+			filtered := New(processes).Filter(tt.filter)
+
+			// Should have one root PID
+			if rootPids := filtered.Roots(); len(rootPids) != 1 || rootPids[0] != 1 {
+				t.Errorf("Expected root PID 1, got %v", rootPids)
+			}
+
+			// Capture output
+			var buf strings.Builder
+			if err := r.Render(&buf, filtered); err != nil {
+				t.Fatalf("Render() error: %v", err)
+			}
+
+			// Get lines from output
+			output := strings.TrimRight(buf.String(), "\n")
+			var lines []string
+			if output != "" {
+				lines = strings.Split(output, "\n")
+			}
+
+			// Compare output
+			if len(lines) != len(tt.expected) {
+				t.Errorf("Expected %d lines, got %d", len(tt.expected), len(lines))
+				t.Logf("Got:\n%s", strings.Join(lines, "\n"))
+				return
+			}
+
+			for i, expected := range tt.expected {
+				if i >= len(lines) {
+					t.Errorf("Missing line %d: expected %q", i, expected)
+					continue
+				}
+				if lines[i] != expected {
+					t.Errorf("Line %d mismatch:\ngot:      %q\nexpected: %q", i, lines[i], expected)
+				}
+			}
+		})
+	}
+}
+
+func TestNoHeaderWhenNoProcesses(t *testing.T) {
+	// Create test processes
+	processes := []Process{
+		{
+			PID:       1,
+			PPID:      0,
+			User:      "root",
+			CPUPct:    0.0,
+			MemPct:    0.0,
+			RSSKB:     1024.0,
+			StartTime: nil,
+			CPUTime:   0,
+			Command:   "init",
+		},
+		{
+			PID:       10,
+			PPID:      1,
+			User:      "user1",
+			CPUPct:    0.0,
+			MemPct:    0.0,
+			RSSKB:     1024.0,
+			StartTime: nil,
+			CPUTime:   0,
+			Command:   "process1",
+		},
+		{
+			PID:       20,
+			PPID:      1,
+			User:      "user2",
+			CPUPct:    0.0,
+			MemPct:    0.0,
+			RSSKB:     1024.0,
+			StartTime: nil,
+			CPUTime:   0,
+			Command:   "process2",
+		},
+	}
+
+	tests := []struct {
+		name           string
+		filter         Filter
+		noProcesses    bool
+		expectedOutput string // Empty string means no output expected
+	}{
+		{
+			name: "filter with no matches - no header printed",
+			filter: Filter{
+				Users: []string{"nonexistentuser"},
+			},
+			expectedOutput: "",
+		},
+		{
+			name: "filter by non-existent PID - no header printed",
+			filter: Filter{
+				PIDs: []int{9999},
+			},
+			expectedOutput: "",
+		},
+		{
+			name: "filter by non-matching string - no header printed",
+			filter: Filter{
+				Strings: []string{"nonexistentprocess"},
+			},
+			expectedOutput: "",
+		},
+		{
+			name:           "no processes - no header printed",
+			filter:         Filter{},
+			noProcesses:    true,
+			expectedOutput: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testProcesses := processes
+			if tt.noProcesses {
+				testProcesses = nil
+			}
+
+			// Create a test Renderer
+			r := &Renderer{
+				Indent: 2, // Set default indentation
+				Width:  80,
+			}
+
+			// Apply filters
+			filtered := New(testProcesses).Filter(tt.filter)
+
+			// Capture output
+			var buf strings.Builder
+			if err := r.Render(&buf, filtered); err != nil {
+				t.Fatalf("Render() error: %v", err)
+			}
+
+			// Check if output matches expected; in particular, no header
+			output := strings.TrimSpace(buf.String())
+			if output != tt.expectedOutput {
+				t.Errorf("Expected output %q, got %q", tt.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestIndentation(t *testing.T) {
+	// Create simple test processes
+	processes := []Process{
+		{
+			PID:       1,
+			PPID:      0,
+			User:      "root",
+			CPUPct:    0.0,
+			MemPct:    0.0,
+			RSSKB:     1024.0,
+			StartTime: nil,
+			CPUTime:   0,
+			Command:   "init",
+		},
+		{
+			PID:       10,
+			PPID:      1,
+			User:      "user",
+			CPUPct:    0.0,
+			MemPct:    0.0,
+			RSSKB:     1024.0,
+			StartTime: nil,
+			CPUTime:   0,
+			Command:   "parent",
+		},
+		{
+			PID:       20,
+			PPID:      10,
+			User:      "user",
+			CPUPct:    0.0,
+			MemPct:    0.0,
+			RSSKB:     1024.0,
+			StartTime: nil,
+			CPUTime:   0,
+			Command:   "child1",
+		},
+		{
+			PID:       21,
+			PPID:      10,
+			User:      "user",
+			CPUPct:    0.0,
+			MemPct:    0.0,
+			RSSKB:     1024.0,
+			StartTime: nil,
+			CPUTime:   0,
+			Command:   "child2",
+		},
+		{
+			PID:       30,
+			PPID:      20,
+			User:      "user",
+			CPUPct:    0.0,
+			MemPct:    0.0,
+			RSSKB:     1024.0,
+			StartTime: nil,
+			CPUTime:   0,
+			Command:   "grandchild",
+		},
+	}
+
+	tests := []struct {
+		name         string
+		indentSize   int
+		expectedTree []string
+	}{
+		{
+			name:       "default indentation (2 spaces)",
+			indentSize: 2,
+			expectedTree: []string{
+				"   PID     USER     %CPU  %MEM   RSS   START    TIME    COMMAND",
+				"--------------------------------------------------------------------------------",
+				"      1 root         0.0   0.0   1.0M  --           --  ─┬─ init",
+				"     10 user         0.0   0.0   1.0M  --           --   └─┬─ parent",
+				"     20 user         0.0   0.0   1.0M  --           --     ├─┬─ child1",
+				"     30 user         0.0   0.0   1.0M  --           --     │ └─── grandchild",
+				"     21 user         0.0   0.0   1.0M  --           --     └─── child2",
+			},
+		},
+		{
+			name:       "single space indentation",
+			indentSize: 1,
+			expectedTree: []string{
+				"   PID     USER     %CPU  %MEM   RSS   START    TIME    COMMAND",
+				"--------------------------------------------------------------------------------",
+				"      1 root         0.0   0.0   1.0M  --           --  ─┬ init",
+				"     10 user         0.0   0.0   1.0M  --           --   └┬ parent",
+				"     20 user         0.0   0.0   1.0M  --           --    ├┬ child1",
+				"     30 user         0.0   0.0   1.0M  --           --    │└─ grandchild",
+				"     21 user         0.0   0.0   1.0M  --           --    └─ child2",
+			},
+		},
+		{
+			name:       "4 space indentation",
+			indentSize: 4,
+			expectedTree: []string{
+				"   PID     USER     %CPU  %MEM   RSS   START    TIME    COMMAND",
+				"--------------------------------------------------------------------------------",
+				"      1 root         0.0   0.0   1.0M  --           --  ─┬─── init",
+				"     10 user         0.0   0.0   1.0M  --           --   └───┬─── parent",
+				"     20 user         0.0   0.0   1.0M  --           --       ├───┬─── child1",
+				"     30 user         0.0   0.0   1.0M  --           --       │   └─────── grandchild",
+				"     21 user         0.0   0.0   1.0M  --           --       └─────── child2",
+			},
+		},
+		{
+			name:       "10 space indentation",
+			indentSize: 10,
+			expectedTree: []string{
+				"   PID     USER     %CPU  %MEM   RSS   START    TIME    COMMAND",
+				"--------------------------------------------------------------------------------",
+				"      1 root         0.0   0.0   1.0M  --           --  ─┬───────── init",
+				"     10 user         0.0   0.0   1.0M  --           --   └─────────┬───────── parent",
+				"     20 user         0.0   0.0   1.0M  --           --             ├─────────┬───────── child1",
+				"     30 user         0.0   0.0   1.0M  --           --             │         └─────────────────── grandchild",
+				"     21 user         0.0   0.0   1.0M  --           --             └─────────────────── child2",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create a test Renderer with specified indentation
+			r := &Renderer{
+				Indent: tt.indentSize,
+				Width:  0,
+			}
+
+			// No filters - show all
+			all := New(processes)
+
+			// Capture output
+			var buf strings.Builder
+			if err := r.Render(&buf, all); err != nil {
+				t.Fatalf("Render() error: %v", err)
+			}
+
+			// Get lines from output
+			output := strings.TrimRight(buf.String(), "\n")
+			var lines []string
+			if output != "" {
+				lines = strings.Split(output, "\n")
+			}
+
+			// Compare output
+			if len(lines) != len(tt.expectedTree) {
+				t.Errorf("Expected %d lines, got %d", len(tt.expectedTree), len(lines))
+				t.Logf("Got:\n%s", strings.Join(lines, "\n"))
+				return
+			}
+
+			for i, expected := range tt.expectedTree {
+				if i >= len(lines) {
+					t.Errorf("Missing line %d: expected %q", i, expected)
+					continue
+				}
+				if lines[i] != expected {
+					t.Errorf("Line %d mismatch:\ngot:      %q\nexpected: %q", i, lines[i], expected)
+				}
+			}
+		})
+	}
+}
+
+func TestExtraColumns(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, User: "root", RSSKB: 1024.0, Nice: 0, Command: "init"},
+		{PID: 10, PPID: 1, User: "user", RSSKB: 1024.0, Nice: 10, Command: "make"},
+		{PID: 20, PPID: 10, User: "user", RSSKB: 1024.0, Nice: -5, Command: "cc"},
+	}
+
+	r := &Renderer{
+		Indent: 2,
+		Columns: []Column{
+			{Header: "NI", Value: func(p *Process) string { return strconv.Itoa(p.Nice) }},
+			{Header: "TAG", Value: func(p *Process) string { return p.User[:1] }, Left: true},
+		},
+	}
+
+	expected := []string{
+		"   PID     USER     %CPU  %MEM   RSS   START    TIME    NI  TAG  COMMAND",
+		"--------------------------------------------------------------------------------",
+		"      1 root         0.0   0.0   1.0M  --           --   0  r    ─┬─ init",
+		"     10 user         0.0   0.0   1.0M  --           --  10  u     └─┬─ make",
+		"     20 user         0.0   0.0   1.0M  --           --  -5  u       └─── cc",
+	}
+
+	var buf strings.Builder
+	if err := r.Render(&buf, New(processes)); err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines, got %d:\n%s", len(expected), len(lines), buf.String())
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("Line %d mismatch:\ngot:      %q\nexpected: %q", i, lines[i], expected[i])
+		}
+	}
+}
+
+func TestTags(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, User: "root", RSSKB: 1024.0, Command: "init"},
+		{PID: 10, PPID: 1, User: "user", RSSKB: 1024.0, Command: "worker"},
+	}
+
+	r := &Renderer{
+		Indent: 2,
+		Tag: func(p *Process) string {
+			if p.PID == 10 {
+				return "[orphan]"
+			}
+			return ""
+		},
+	}
+
+	expected := []string{
+		"   PID     USER     %CPU  %MEM   RSS   START    TIME    COMMAND",
+		"--------------------------------------------------------------------------------",
+		"      1 root         0.0   0.0   1.0M  --           --  ─┬─ init",
+		"     10 user         0.0   0.0   1.0M  --           --   └─── [orphan] worker",
+	}
+
+	var buf strings.Builder
+	if err := r.Render(&buf, New(processes)); err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines, got %d:\n%s", len(expected), len(lines), buf.String())
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("Line %d mismatch:\ngot:      %q\nexpected: %q", i, lines[i], expected[i])
+		}
+	}
+}
+
+func TestFileLeaves(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, User: "root", RSSKB: 1024.0, Command: "init", Files: []File{{0, "/dev/null"}}},
+		{PID: 10, PPID: 1, User: "user", RSSKB: 1024.0, Command: "app", Files: []File{{1, "/var/log/app.log (deleted)"}, {3, "socket:[12]"}}},
+		{PID: 11, PPID: 10, User: "user", RSSKB: 1024.0, Command: "worker", Files: []File{{4, "/tmp/lock"}}},
+		{PID: 20, PPID: 1, User: "user", RSSKB: 1024.0, Command: "vim"},
+	}
+
+	r := &Renderer{Indent: 2, Files: true}
+
+	expected := []string{
+		"   PID     USER     %CPU  %MEM   RSS   START    TIME    COMMAND",
+		"--------------------------------------------------------------------------------",
+		"      1 root         0.0   0.0   1.0M  --           --  ─┬─ init",
+		"                                                         ├─── 0: /dev/null",
+		"     10 user         0.0   0.0   1.0M  --           --   ├─┬─ app",
+		"                                                         │ ├─── 1: /var/log/app.log (deleted)",
+		"                                                         │ ├─── 3: socket:[12]",
+		"     11 user         0.0   0.0   1.0M  --           --   │ └─┬─ worker",
+		"                                                         │   └─── 4: /tmp/lock",
+		"     20 user         0.0   0.0   1.0M  --           --   └─── vim",
+	}
+
+	var buf strings.Builder
+	if err := r.Render(&buf, New(processes)); err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines, got %d:\n%s", len(expected), len(lines), buf.String())
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("Line %d mismatch:\ngot:      %q\nexpected: %q", i, lines[i], expected[i])
+		}
+	}
+
+	// Without Files, processes keep their plain branches
+	buf.Reset()
+	r.Files = false
+	if err := r.Render(&buf, New(processes)); err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	if strings.Contains(buf.String(), "/dev/null") || !strings.Contains(buf.String(), "└─── worker") {
+		t.Errorf("files shown without Files:\n%s", buf.String())
+	}
+}
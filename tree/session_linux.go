@@ -0,0 +1,66 @@
+package tree
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ReadSessions fills in the Session of each process from /proc, returning the
+// number of processes that couldn't be read, usually for having exited
+func ReadSessions(processes []Process) (int, error) {
+	unreadable := 0
+	for i := range processes {
+		p := &processes[i]
+		fields, err := readStat(p.PID)
+		if err != nil {
+			unreadable++
+			continue
+		}
+		session, err := statSession(fields)
+		if err != nil {
+			return unreadable, fmt.Errorf("/proc/%d/stat: %v", p.PID, err)
+		}
+		p.Session = session
+	}
+	return unreadable, nil
+}
+
+// statSession returns the session of stat fields
+func statSession(fields []string) (*Session, error) {
+	// state ppid pgrp session tty_nr ...
+	if len(fields) < 5 {
+		return nil, fmt.Errorf("too few fields: %d", len(fields))
+	}
+	var numbers [3]int
+	for i, field := range fields[2:5] {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number: %s", field)
+		}
+		numbers[i] = n
+	}
+	return &Session{PGID: numbers[0], SID: numbers[1], TTY: ttyName(uint32(numbers[2]))}, nil
+}
+
+// ttyName names a terminal by its device number, as in /proc/<pid>/stat, e.g.
+// "pts/3"; "" for none. Terminals other than the usual ones are named by
+// major,minor number.
+func ttyName(dev uint32) string {
+	if dev == 0 {
+		return ""
+	}
+	major := (dev >> 8) & 0xfff
+	minor := (dev & 0xff) | ((dev >> 12) & 0xfff00)
+
+	switch {
+	case major >= 136 && major <= 143: // Unix98 pseudo-terminals
+		return fmt.Sprintf("pts/%d", (major-136)<<8+minor)
+	case major == 4 && minor < 64: // Virtual consoles
+		return fmt.Sprintf("tty%d", minor)
+	case major == 4: // Serial ports
+		return fmt.Sprintf("ttyS%d", minor-64)
+	case major == 5 && minor == 1:
+		return "console"
+	}
+	return fmt.Sprintf("%d,%d", major, minor)
+}
@@ -0,0 +1,67 @@
+package tree
+
+import (
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestStatSession(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected Session
+	}{
+		{"4242 (bash) S 4241 4242 4242 34819 4300 4194304 0 0 0 0 1 0", Session{SID: 4242, PGID: 4242, TTY: "pts/3"}},
+		{"4300 (sleep) S 4242 4300 4242 34819 4300 4194304 0 0 0 0 1 0", Session{SID: 4242, PGID: 4300, TTY: "pts/3"}},
+		{"812 (cron) S 1 812 812 0 -1 4194624 0 0 0 0 1 0", Session{SID: 812, PGID: 812}},
+	}
+
+	for _, tt := range tests {
+		fields, _ := parseStat(tt.line)
+		got, err := statSession(fields)
+		if err != nil || *got != tt.expected {
+			t.Errorf("statSession(%q) = %+v, %v, want %+v", tt.line, got, err, tt.expected)
+		}
+	}
+
+	if _, err := statSession([]string{"S", "1", "x", "1", "0"}); err == nil {
+		t.Errorf("statSession() of an invalid group succeeded")
+	}
+}
+
+func TestTTYName(t *testing.T) {
+	tests := []struct {
+		dev      uint32
+		expected string
+	}{
+		{0, ""},
+		{136<<8 | 3, "pts/3"},
+		{137<<8 | 2, "pts/258"},
+		{136<<8 | 0x45<<20 | 0x21, "pts/17697"}, // Minor above 255
+		{4<<8 | 1, "tty1"},
+		{4<<8 | 65, "ttyS1"},
+		{5<<8 | 1, "console"},
+		{204<<8 | 64, "204,64"},
+	}
+
+	for _, tt := range tests {
+		if got := ttyName(tt.dev); got != tt.expected {
+			t.Errorf("ttyName(%#x) = %q, want %q", tt.dev, got, tt.expected)
+		}
+	}
+}
+
+func TestReadSessions(t *testing.T) {
+	processes := []Process{{PID: os.Getpid()}, {PID: 1 << 30}}
+	unreadable, err := ReadSessions(processes)
+	if err != nil || unreadable != 1 {
+		t.Fatalf("ReadSessions() = %d, %v, want 1, nil", unreadable, err)
+	}
+	sid, _, _ := syscall.RawSyscall(syscall.SYS_GETSID, 0, 0, 0)
+	if s := processes[0].Session; s == nil || s.SID != int(sid) || s.PGID != syscall.Getpgrp() {
+		t.Errorf("ReadSessions() of ourselves = %+v, want SID %d, PGID %d", s, sid, syscall.Getpgrp())
+	}
+	if processes[1].Session != nil {
+		t.Errorf("ReadSessions() of an exited process = %+v", processes[1].Session)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package tree
+
+// ReadSessions is only supported on Unix systems
+func ReadSessions(processes []Process) (int, error) {
+	return 0, nil
+}
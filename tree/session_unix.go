@@ -0,0 +1,60 @@
+//go:build unix && !linux
+
+package tree
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ReadSessions fills in the Session of each process from ps, and the session
+// IDs, which ps doesn't give, from getsid, returning the number of processes
+// that couldn't be read
+func ReadSessions(processes []Process) (int, error) {
+	output, err := exec.Command("ps", "-axo", "pid=,pgid=,tty=").Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to run ps: %v", err)
+	}
+
+	sessions := make(map[int]*Session)
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		pgid, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		tty := fields[2]
+		if tty == "??" {
+			tty = ""
+		}
+		sessions[pid] = &Session{PGID: pgid, TTY: tty}
+	}
+
+	unreadable := 0
+	for i := range processes {
+		p := &processes[i]
+		session, ok := sessions[p.PID]
+		if !ok {
+			unreadable++
+			continue
+		}
+		if session.SID, err = syscall.Getsid(p.PID); err != nil {
+			unreadable++
+			continue
+		}
+		p.Session = session
+	}
+	return unreadable, nil
+}
@@ -0,0 +1,218 @@
+package tree
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ReadSockets fills in the Sockets of each process from /proc, returning the
+// number of processes whose open files couldn't be read, usually for lack of
+// permission. Sockets are looked up in each process's network namespace, among
+// the Files of the process if already read.
+func ReadSockets(processes []Process) (int, error) {
+	tables := make(map[string]map[uint64]Socket) // By network namespace
+	unreadable := 0
+
+	for i := range processes {
+		p := &processes[i]
+		files := p.Files
+		if files == nil {
+			var err error
+			if files, err = openFiles(p.PID); err != nil {
+				unreadable++
+				continue
+			}
+		}
+		inodes := socketInodes(files)
+		p.Sockets = []Socket{}
+		if len(inodes) == 0 {
+			continue
+		}
+
+		ns, _ := os.Readlink(fmt.Sprintf("/proc/%d/ns/net", p.PID))
+		table, ok := tables[ns]
+		if !ok {
+			var err error
+			if table, err = readSocketTables(fmt.Sprintf("/proc/%d/net", p.PID)); err != nil {
+				// Exited since its open files were read
+				p.Sockets = nil
+				unreadable++
+				continue
+			}
+			tables[ns] = table
+		}
+
+		for _, inode := range inodes {
+			if socket, ok := table[inode]; ok {
+				p.Sockets = append(p.Sockets, socket)
+			}
+		}
+		sort.Slice(p.Sockets, func(i, j int) bool {
+			a, b := p.Sockets[i], p.Sockets[j]
+			if a.Proto != b.Proto {
+				return a.Proto < b.Proto
+			}
+			if a.Port != b.Port {
+				return a.Port < b.Port
+			}
+			return a.Local < b.Local
+		})
+	}
+
+	return unreadable, nil
+}
+
+// socketInodes returns the inodes of the sockets among open files
+func socketInodes(files []File) []uint64 {
+	var inodes []uint64
+	seen := make(map[uint64]bool)
+	for _, file := range files {
+		if !strings.HasPrefix(file.Path, "socket:[") {
+			continue
+		}
+		inode, err := strconv.ParseUint(strings.TrimSuffix(file.Path[len("socket:["):], "]"), 10, 64)
+		if err == nil && !seen[inode] {
+			seen[inode] = true
+			inodes = append(inodes, inode)
+		}
+	}
+	return inodes
+}
+
+// readSocketTables reads the sockets of a network namespace, by inode, from a
+// /proc net directory
+func readSocketTables(dir string) (map[uint64]Socket, error) {
+	table := make(map[uint64]Socket)
+	for _, proto := range []string{"tcp", "tcp6", "udp", "udp6", "unix"} {
+		f, err := os.Open(filepath.Join(dir, proto))
+		if os.IsNotExist(err) {
+			// No IPv6, for one
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if proto == "unix" {
+			err = parseUnixSockets(f, table)
+		} else {
+			err = parseInetSockets(f, proto, table)
+		}
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", f.Name(), err)
+		}
+	}
+	return table, nil
+}
+
+// TCP and UDP socket states in /proc/net/{tcp,udp}
+const (
+	tcpListen = "0A"
+	udpClose  = "07"
+)
+
+// parseInetSockets adds the sockets of a /proc/net/{tcp,tcp6,udp,udp6} table
+func parseInetSockets(r io.Reader, proto string, table map[uint64]Socket) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // Skip header
+
+	for scanner.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		ip, port, err := parseProcAddress(fields[1])
+		if err != nil {
+			return err
+		}
+		_, remotePort, err := parseProcAddress(fields[2])
+		if err != nil {
+			return err
+		}
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid inode: %s", fields[9])
+		}
+
+		listen := fields[3] == tcpListen
+		if strings.HasPrefix(proto, "udp") {
+			listen = fields[3] == udpClose && remotePort == 0
+		}
+		table[inode] = Socket{
+			Proto:  proto,
+			Local:  net.JoinHostPort(ip.String(), strconv.Itoa(port)),
+			Port:   port,
+			Listen: listen,
+		}
+	}
+	return scanner.Err()
+}
+
+// parseProcAddress parses an address of /proc/net/{tcp,udp}, e.g.
+// "0100007F:1F90" for 127.0.0.1:8080 on little-endian machines. The IP is
+// written as 32-bit words in host byte order.
+func parseProcAddress(s string) (net.IP, int, error) {
+	hexIP, hexPort, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, 0, fmt.Errorf("invalid address: %s", s)
+	}
+	ip, err := hex.DecodeString(hexIP)
+	if err != nil || (len(ip) != net.IPv4len && len(ip) != net.IPv6len) {
+		return nil, 0, fmt.Errorf("invalid address: %s", s)
+	}
+	port, err := strconv.ParseUint(hexPort, 16, 16)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid address: %s", s)
+	}
+
+	for word := 0; word < len(ip); word += 4 {
+		binary.BigEndian.PutUint32(ip[word:], binary.NativeEndian.Uint32(ip[word:]))
+	}
+	return net.IP(ip), int(port), nil
+}
+
+// unixAcceptConnections is __SO_ACCEPTCON in the flags of /proc/net/unix
+const unixAcceptConnections = 0x10000
+
+// parseUnixSockets adds the sockets of a /proc/net/unix table
+func parseUnixSockets(r io.Reader, table map[uint64]Socket) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // Skip header
+
+	for scanner.Scan() {
+		// Num RefCount Protocol Flags Type St Inode [Path]
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 7 {
+			continue
+		}
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil {
+			return fmt.Errorf("invalid flags: %s", fields[3])
+		}
+		inode, err := strconv.ParseUint(fields[6], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid inode: %s", fields[6])
+		}
+
+		var path string
+		if len(fields) > 7 {
+			path = strings.Join(fields[7:], " ")
+		}
+		table[inode] = Socket{
+			Proto:  "unix",
+			Local:  path,
+			Listen: flags&unixAcceptConnections != 0,
+		}
+	}
+	return scanner.Err()
+}
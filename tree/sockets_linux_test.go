@@ -0,0 +1,110 @@
+package tree
+
+import (
+	"encoding/binary"
+	"net"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseInetSockets(t *testing.T) {
+	if binary.NativeEndian.Uint16([]byte{1, 0}) != 1 {
+		t.Skip("sample tables are from a little-endian machine")
+	}
+
+	tcp := `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 31337 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:1F90 0100007F:C350 01 00000000:00000000 00:00000000 00000000  1000        0 31338 1 0000000000000000 20 4 30 10 -1
+`
+	tcp6 := `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:0016 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 2222 1 0000000000000000 100 0 0 10 0
+   1: 0000000000000000FFFF00000100007F:01BB 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 4443 1 0000000000000000 100 0 0 10 0
+`
+	udp := `   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  100: 3500007F:0035 00000000:0000 07 00000000:00000000 00:00000000 00000000   101        0 5353 2 0000000000000000 0
+  101: 0F02000A:D431 08080808:0035 01 00000000:00000000 00:00000000 00000000  1000        0 5454 2 0000000000000000 0
+`
+
+	table := make(map[uint64]Socket)
+	for proto, data := range map[string]string{"tcp": tcp, "tcp6": tcp6, "udp": udp} {
+		if err := parseInetSockets(strings.NewReader(data), proto, table); err != nil {
+			t.Fatalf("parseInetSockets(%s) error: %v", proto, err)
+		}
+	}
+
+	expected := map[uint64]Socket{
+		31337: {Proto: "tcp", Local: "127.0.0.1:8080", Port: 8080, Listen: true},
+		31338: {Proto: "tcp", Local: "127.0.0.1:8080", Port: 8080},
+		2222:  {Proto: "tcp6", Local: "[::]:22", Port: 22, Listen: true},
+		4443:  {Proto: "tcp6", Local: "127.0.0.1:443", Port: 443, Listen: true}, // IPv4-mapped
+		5353:  {Proto: "udp", Local: "127.0.0.53:53", Port: 53, Listen: true},
+		5454:  {Proto: "udp", Local: "10.0.2.15:54321", Port: 54321},
+	}
+	if len(table) != len(expected) {
+		t.Errorf("parsed %d sockets, want %d: %v", len(table), len(expected), table)
+	}
+	for inode, socket := range expected {
+		if table[inode] != socket {
+			t.Errorf("socket %d = %+v, want %+v", inode, table[inode], socket)
+		}
+	}
+
+	if err := parseInetSockets(strings.NewReader("header\n0: nonsense 00000000:0000 0A 0 0 0 0 0 1\n"), "tcp", table); err == nil {
+		t.Errorf("parseInetSockets() accepted an invalid address")
+	}
+}
+
+func TestParseUnixSockets(t *testing.T) {
+	unix := `Num       RefCount Protocol Flags    Type St Inode Path
+0000000000000000: 00000002 00000000 00010000 0001 01 17000 /run/dbus/system_bus_socket
+0000000000000000: 00000003 00000000 00000000 0001 03 17001 /run/dbus/system_bus_socket
+0000000000000000: 00000002 00000000 00000000 0002 01 17002
+0000000000000000: 00000002 00000000 00010000 0001 01 17003 @/tmp/.X11-unix/X0
+`
+	table := make(map[uint64]Socket)
+	if err := parseUnixSockets(strings.NewReader(unix), table); err != nil {
+		t.Fatalf("parseUnixSockets() error: %v", err)
+	}
+
+	expected := map[uint64]Socket{
+		17000: {Proto: "unix", Local: "/run/dbus/system_bus_socket", Listen: true},
+		17001: {Proto: "unix", Local: "/run/dbus/system_bus_socket"},
+		17002: {Proto: "unix"},
+		17003: {Proto: "unix", Local: "@/tmp/.X11-unix/X0", Listen: true},
+	}
+	for inode, socket := range expected {
+		if table[inode] != socket {
+			t.Errorf("socket %d = %+v, want %+v", inode, table[inode], socket)
+		}
+	}
+}
+
+func TestReadSockets(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("can't listen: %v", err)
+	}
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	// A PID that doesn't exist can't be read
+	processes := []Process{{PID: os.Getpid()}, {PID: 1 << 30}}
+	unreadable, err := ReadSockets(processes)
+	if err != nil {
+		t.Fatalf("ReadSockets() error: %v", err)
+	}
+	if unreadable != 1 || processes[1].Sockets != nil {
+		t.Errorf("ReadSockets() unreadable = %d, want 1", unreadable)
+	}
+
+	found := false
+	for _, socket := range processes[0].Sockets {
+		if socket.Proto == "tcp" && socket.Port == port && socket.Listen {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("listening socket on port %d not found in %+v", port, processes[0].Sockets)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package tree
+
+import "errors"
+
+// ReadSockets fills in the Sockets of each process; only Linux is supported
+func ReadSockets(processes []Process) (int, error) {
+	return 0, errors.New("sockets are only available on Linux")
+}
@@ -0,0 +1,85 @@
+package tree
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTick is the unit of CPU times in /proc, USER_HZ, which is 100 on every
+// architecture Go runs Linux on
+const clockTick = time.Second / 100
+
+// ReadCPUTimes fills in the CPUTime of each process from /proc, to the clock
+// tick rather than to the second as ps gives it, returning the number of
+// processes that couldn't be read, usually for having exited. Those keep the
+// CPU time ps gave them, and don't keep the others from being read.
+func ReadCPUTimes(processes []Process) (int, error) {
+	unreadable := 0
+	for i := range processes {
+		p := &processes[i]
+		fields, err := readStat(p.PID)
+		if err != nil {
+			unreadable++
+			continue
+		}
+		cpuTime, err := statCPUTime(fields)
+		if err != nil {
+			unreadable++
+			continue
+		}
+		p.CPUTime = cpuTime
+	}
+	return unreadable, nil
+}
+
+// readStat returns the fields of /proc/<pid>/stat that follow the command
+func readStat(pid int) ([]string, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return nil, err
+	}
+	return parseStat(string(data))
+}
+
+// parseStat returns the fields of a /proc/<pid>/stat line that follow the
+// command, starting with the state. The command is in parentheses and may
+// contain anything, parentheses and spaces included.
+func parseStat(line string) ([]string, error) {
+	end := strings.LastIndexByte(line, ')')
+	if end < 0 {
+		return nil, fmt.Errorf("invalid stat: %s", line)
+	}
+	return strings.Fields(line[end+1:]), nil
+}
+
+// statCPUTime returns the user plus system CPU time of stat fields
+func statCPUTime(fields []string) (time.Duration, error) {
+	// state ppid pgrp session tty_nr tpgid flags minflt cminflt majflt cmajflt utime stime ...
+	if len(fields) < 13 {
+		return 0, fmt.Errorf("too few fields: %d", len(fields))
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid utime: %s", fields[11])
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid stime: %s", fields[12])
+	}
+	return time.Duration(utime+stime) * clockTick, nil
+}
+
+// Stopped reports whether pid is stopped, as by SIGSTOP, from /proc
+func Stopped(pid int) (bool, error) {
+	fields, err := readStat(pid)
+	if err != nil {
+		return false, err
+	}
+	if len(fields) == 0 {
+		return false, fmt.Errorf("/proc/%d/stat: no state", pid)
+	}
+	return fields[0] == "T", nil
+}
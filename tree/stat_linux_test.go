@@ -0,0 +1,82 @@
+package tree
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestStatCPUTime(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected time.Duration
+	}{
+		{"1234 (sleep) S 1 1234 1234 0 -1 4194304 93 0 0 0 250 31 0 0 20 0 1 0 5000 1000 100", 2810 * time.Millisecond},
+		{"42 (odd) name (x)) R 1 42 42 34816 42 4194560 120 0 0 0 7 3 0 0 20 0 1 0 9 9 9", 100 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		fields, err := parseStat(tt.line)
+		if err != nil {
+			t.Errorf("parseStat(%q) error: %v", tt.line, err)
+			continue
+		}
+		got, err := statCPUTime(fields)
+		if err != nil || got != tt.expected {
+			t.Errorf("statCPUTime(%q) = %v, %v, want %v", tt.line, got, err, tt.expected)
+		}
+	}
+
+	if _, err := parseStat("1234 sleep"); err == nil {
+		t.Errorf("parseStat() of a line without a command succeeded")
+	}
+	if _, err := statCPUTime([]string{"S", "1"}); err == nil {
+		t.Errorf("statCPUTime() of a short line succeeded")
+	}
+}
+
+func TestReadCPUTimes(t *testing.T) {
+	// The exited process comes first, and mustn't keep the rest from being read
+	processes := []Process{{PID: 1 << 30, CPUTime: time.Hour}, {PID: os.Getpid(), CPUTime: -1}}
+	unreadable, err := ReadCPUTimes(processes)
+	if err != nil || unreadable != 1 {
+		t.Fatalf("ReadCPUTimes() = %d, %v, want 1, nil", unreadable, err)
+	}
+	if processes[0].CPUTime != time.Hour {
+		t.Errorf("ReadCPUTimes() changed the CPU time of an exited process to %v", processes[0].CPUTime)
+	}
+	if processes[1].CPUTime < 0 {
+		t.Errorf("ReadCPUTimes() didn't read our own CPU time")
+	}
+}
+
+func TestStopped(t *testing.T) {
+	if stopped, err := Stopped(os.Getpid()); err != nil || stopped {
+		t.Errorf("Stopped(self) = %v, %v, want false, nil", stopped, err)
+	}
+	if _, err := Stopped(1 << 30); err == nil {
+		t.Errorf("Stopped() of a missing process succeeded")
+	}
+
+	cmd := exec.Command("sleep", "10")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("can't start sleep: %v", err)
+	}
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+	if err := cmd.Process.Signal(syscall.SIGSTOP); err != nil {
+		t.Fatal(err)
+	}
+	// The signal is delivered asynchronously
+	for i := 0; i < 100; i++ {
+		if stopped, _ := Stopped(cmd.Process.Pid); stopped {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("Stopped() of a stopped process = false")
+}
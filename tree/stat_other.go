@@ -0,0 +1,25 @@
+//go:build !linux
+
+package tree
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ReadCPUTimes fills in the CPUTime of each process. ps already gives them to
+// the hundredth of a second outside Linux, so there's nothing to add.
+func ReadCPUTimes(processes []Process) (int, error) {
+	return 0, nil
+}
+
+// Stopped reports whether pid is stopped, as by SIGSTOP, from ps
+func Stopped(pid int) (bool, error) {
+	output, err := exec.Command("ps", "-o", "stat=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to run ps: %v", err)
+	}
+	return strings.HasPrefix(strings.TrimSpace(string(output)), "T"), nil
+}
@@ -0,0 +1,327 @@
+// Package tree builds, filters and renders process trees.
+//
+// A typical use collects processes from the platform, filters them and renders
+// the result:
+//
+//	processes, err := tree.GetPlatform().GetProcesses()
+//	if err != nil {
+//		return err
+//	}
+//	t := tree.New(processes).Filter(tree.Filter{Users: []string{"postgres"}})
+//	err = (&tree.Renderer{}).Render(os.Stdout, t)
+package tree
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Tree holds processes and their parent-child relationships.
+//
+// A filtered Tree shares its processes with the Tree it came from, but only
+// the processes selected by the filter are visible: every method except
+// Process considers visible processes only.
+type Tree struct {
+	processes map[int]*Process
+	children  map[int][]int // Sorted child PIDs of each PID
+	visible   map[int]bool  // PIDs shown by the filter, nil if unfiltered
+	matched   map[int]bool  // PIDs matching the filter itself, nil if unfiltered
+}
+
+// New builds a Tree from a process list. The Tree keeps pointers into processes.
+func New(processes []Process) *Tree {
+	t := &Tree{
+		processes: make(map[int]*Process, len(processes)),
+		children:  make(map[int][]int),
+	}
+
+	for i := range processes {
+		p := &processes[i]
+		t.processes[p.PID] = p
+		if p.PPID > 0 {
+			t.children[p.PPID] = append(t.children[p.PPID], p.PID)
+		}
+	}
+	for _, childPids := range t.children {
+		sort.Ints(childPids)
+	}
+
+	return t
+}
+
+// Process returns the process with the given PID, or nil if there is none.
+// Processes hidden by a filter are still returned.
+func (t *Tree) Process(pid int) *Process {
+	return t.processes[pid]
+}
+
+// Visible reports whether pid exists and is shown by the filter
+func (t *Tree) Visible(pid int) bool {
+	if _, ok := t.processes[pid]; !ok {
+		return false
+	}
+	return t.visible == nil || t.visible[pid]
+}
+
+// Filtered reports whether the Tree is the result of a non-empty Filter
+func (t *Tree) Filtered() bool {
+	return t.visible != nil
+}
+
+// Matched reports whether pid itself matched the filter, as opposed to being
+// shown as an ancestor or descendant of a match. Always false if unfiltered.
+func (t *Tree) Matched(pid int) bool {
+	return t.matched[pid]
+}
+
+// PIDs returns the sorted PIDs of all visible processes
+func (t *Tree) PIDs() []int {
+	var pids []int
+	for pid := range t.processes {
+		if t.Visible(pid) {
+			pids = append(pids, pid)
+		}
+	}
+	sort.Ints(pids)
+	return pids
+}
+
+// Roots returns the sorted PIDs of visible processes without a visible parent
+func (t *Tree) Roots() []int {
+	var roots []int
+	for pid, p := range t.processes {
+		if t.Visible(pid) && (p.PPID == 0 || !t.Visible(p.PPID)) {
+			roots = append(roots, pid)
+		}
+	}
+	sort.Ints(roots)
+	return roots
+}
+
+// Children returns the sorted PIDs of the visible children of pid
+func (t *Tree) Children(pid int) []int {
+	var children []int
+	for _, child := range t.children[pid] {
+		if t.Visible(child) {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// Ancestors returns the visible ancestors of pid, starting with its parent
+func (t *Tree) Ancestors(pid int) []int {
+	var ancestors []int
+	seen := map[int]bool{pid: true}
+
+	p, ok := t.processes[pid]
+	for ok && p.PPID > 0 && !seen[p.PPID] && t.Visible(p.PPID) {
+		seen[p.PPID] = true
+		ancestors = append(ancestors, p.PPID)
+		p, ok = t.processes[p.PPID]
+	}
+
+	return ancestors
+}
+
+// Descendants returns the visible descendants of pid, breadth first
+func (t *Tree) Descendants(pid int) []int {
+	var descendants []int
+	visited := map[int]bool{pid: true}
+	queue := []int{pid}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, child := range t.Children(current) {
+			if !visited[child] {
+				visited[child] = true
+				descendants = append(descendants, child)
+				queue = append(queue, child)
+			}
+		}
+	}
+
+	return descendants
+}
+
+// Filter selects processes. A process matches if it matches any criterion.
+type Filter struct {
+	PIDs               []int
+	Users              []string
+	Strings            []string              // Command contains the string, case-sensitively
+	StringsInsensitive []string              // Command contains the string, case-insensitively
+	Ports              []int                 // A TCP or UDP socket is bound to the local port; needs Sockets
+	Files              []string              // Has the file open, by absolute path, even if deleted; needs Files
+	Env                []string              // Has the environment variable "KEY", or "KEY=VALUE"; needs Env
+	TTYs               []string              // Controlling terminal, e.g. "pts/3"; needs Session
+	Func               func(p *Process) bool // Custom criterion, if not nil
+}
+
+// Empty reports whether the filter has no criteria
+func (f Filter) Empty() bool {
+	return len(f.PIDs) == 0 && len(f.Users) == 0 && len(f.Strings) == 0 &&
+		len(f.StringsInsensitive) == 0 && len(f.Ports) == 0 &&
+		len(f.Files) == 0 && len(f.Env) == 0 && len(f.TTYs) == 0 && f.Func == nil
+}
+
+// Matches reports whether p matches any of the filter's criteria
+func (f Filter) Matches(p *Process) bool {
+	for _, pid := range f.PIDs {
+		if p.PID == pid {
+			return true
+		}
+	}
+
+	for _, user := range f.Users {
+		if p.User == user {
+			return true
+		}
+	}
+
+	for _, str := range f.Strings {
+		if strings.Contains(p.Command, str) {
+			return true
+		}
+	}
+
+	for _, str := range f.StringsInsensitive {
+		if strings.Contains(strings.ToLower(p.Command), strings.ToLower(str)) {
+			return true
+		}
+	}
+
+	for _, port := range f.Ports {
+		for _, socket := range p.Sockets {
+			if socket.Port == port && socket.Proto != "unix" {
+				return true
+			}
+		}
+	}
+
+	for _, path := range f.Files {
+		for _, file := range p.Files {
+			if file.Path == path || file.Path == path+" (deleted)" {
+				return true
+			}
+		}
+	}
+
+	for _, env := range f.Env {
+		key, value, hasValue := strings.Cut(env, "=")
+		if actual, ok := p.Env[key]; ok && (!hasValue || actual == value) {
+			return true
+		}
+	}
+
+	for _, tty := range f.TTYs {
+		if p.Session != nil && p.Session.TTY == tty {
+			return true
+		}
+	}
+
+	return f.Func != nil && f.Func(p)
+}
+
+// Filter returns a view of the Tree showing the visible processes matching f,
+// along with all their ancestors and descendants. An empty filter returns t.
+func (t *Tree) Filter(f Filter) *Tree {
+	if f.Empty() {
+		return t
+	}
+
+	filtered := &Tree{
+		processes: t.processes,
+		children:  t.children,
+		visible:   make(map[int]bool),
+		matched:   make(map[int]bool),
+	}
+
+	for pid, p := range t.processes {
+		if t.Visible(pid) && f.Matches(p) {
+			filtered.matched[pid] = true
+		}
+	}
+
+	for pid := range filtered.matched {
+		filtered.visible[pid] = true
+		for _, ancestor := range t.Ancestors(pid) {
+			filtered.visible[ancestor] = true
+		}
+		for _, descendant := range t.Descendants(pid) {
+			filtered.visible[descendant] = true
+		}
+	}
+
+	return filtered
+}
+
+// Node is a visible process as visited by Walk
+type Node struct {
+	Process     *Process
+	Depth       int    // 0 for roots
+	Last        bool   // Whether this is the last visible child of its parent (or the last root)
+	HasChildren bool   // Whether the process has visible children
+	Prefix      []bool // For each ancestor level below the root, whether a vertical line continues through it
+}
+
+// WalkFunc is called by Walk for each visible process
+type WalkFunc func(n Node) error
+
+// SkipChildren is used as a return value from a WalkFunc to skip the
+// descendants of the current process. It is not returned as an error by Walk.
+var SkipChildren = errors.New("skip children")
+
+// Walk visits every visible process depth first, in display order: roots and
+// siblings by PID, each parent before its children. It stops at the first
+// error returned by fn, other than SkipChildren.
+func (t *Tree) Walk(fn WalkFunc) error {
+	roots := t.Roots()
+	for i, rootPid := range roots {
+		if err := t.walk(rootPid, 0, []bool{}, i == len(roots)-1, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walk visits pid and its visible descendants
+func (t *Tree) walk(pid int, depth int, prefix []bool, isLast bool, fn WalkFunc) error {
+	childPids := t.Children(pid)
+
+	err := fn(Node{
+		Process:     t.processes[pid],
+		Depth:       depth,
+		Last:        isLast,
+		HasChildren: len(childPids) > 0,
+		Prefix:      prefix,
+	})
+	if err == SkipChildren {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// Determine child prefix based on whether THIS process is last
+	var childPrefix []bool
+	if depth == 0 {
+		// Root's children start with no prefix
+		childPrefix = []bool{}
+	} else {
+		// Copy parent's prefix and add new level
+		childPrefix = make([]bool, len(prefix)+1)
+		copy(childPrefix, prefix)
+		// If this process is last, children get spaces (false)
+		// If this process is not last, children get a vertical line (true)
+		childPrefix[len(prefix)] = !isLast
+	}
+
+	for i, childPid := range childPids {
+		if err := t.walk(childPid, depth+1, childPrefix, i == len(childPids)-1, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+package tree
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// testProcesses returns a small process tree:
+//
+//	1 init
+//	├─ 2 kernel_task
+//	└─ 3 systemd
+//	   ├─ 4 cron
+//	   └─ 5 bash
+//	      └─ 6 vim test.txt
+func testProcesses() []Process {
+	return []Process{
+		{PID: 1, PPID: 0, User: "root", Command: "init"},
+		{PID: 2, PPID: 1, User: "root", Command: "kernel_task"},
+		{PID: 3, PPID: 1, User: "daemon", Command: "systemd"},
+		{PID: 4, PPID: 3, User: "daemon", Command: "cron"},
+		{PID: 5, PPID: 3, User: "user1", Command: "bash"},
+		{PID: 6, PPID: 5, User: "user1", Command: "vim test.txt"},
+	}
+}
+
+func TestProcessFiltering(t *testing.T) {
+	processes := testProcesses()
+
+	tests := []struct {
+		name             string
+		filter           Filter
+		expectedPidsShow map[int]bool
+		expectedRootPids []int
+	}{
+		{
+			name: "filter by PID",
+			filter: Filter{
+				PIDs: []int{5},
+			},
+			expectedPidsShow: map[int]bool{
+				1: true, // ancestor
+				3: true, // ancestor
+				5: true, // matched
+				6: true, // descendant
+			},
+			expectedRootPids: []int{1},
+		},
+		{
+			name: "filter by user",
+			filter: Filter{
+				Users: []string{"daemon"},
+			},
+			expectedPidsShow: map[int]bool{
+				1: true, // ancestor
+				3: true, // matched
+				4: true, // matched (child of 3, also daemon)
+				5: true, // descendant of matched
+				6: true, // descendant of matched
+			},
+			expectedRootPids: []int{1},
+		},
+		{
+			name: "filter by string",
+			filter: Filter{
+				Strings: []string{"vim"},
+			},
+			expectedPidsShow: map[int]bool{
+				1: true, // ancestor
+				3: true, // ancestor
+				5: true, // ancestor
+				6: true, // matched
+			},
+			expectedRootPids: []int{1},
+		},
+		{
+			name: "filter by case-insensitive string",
+			filter: Filter{
+				StringsInsensitive: []string{"VIM"},
+			},
+			expectedPidsShow: map[int]bool{
+				1: true, // ancestor
+				3: true, // ancestor
+				5: true, // ancestor
+				6: true, // matched
+			},
+			expectedRootPids: []int{1},
+		},
+		{
+			name: "multiple filters",
+			filter: Filter{
+				Users:   []string{"user1"},
+				Strings: []string{"bash"},
+			},
+			expectedPidsShow: map[int]bool{
+				1: true, // ancestor
+				3: true, // ancestor
+				5: true, // matched both
+				6: true, // descendant
+			},
+			expectedRootPids: []int{1},
+		},
+		{
+			name:             "no filters",
+			filter:           Filter{},
+			expectedPidsShow: nil,      // No filtering, so the tree should be unfiltered
+			expectedRootPids: []int{1}, // Root process
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered := New(processes).Filter(tt.filter)
+			rootPids := filtered.Roots()
+
+			// Check root PIDs
+			if !equalIntSlices(rootPids, tt.expectedRootPids) {
+				t.Errorf("rootPids = %v, want %v", rootPids, tt.expectedRootPids)
+			}
+
+			// Check PIDs to show
+			if tt.expectedPidsShow == nil {
+				if filtered.Filtered() {
+					t.Errorf("Filtered() = true, want false")
+				}
+			} else {
+				for pid, expected := range tt.expectedPidsShow {
+					if filtered.Visible(pid) != expected {
+						t.Errorf("PID %d: got %v, want %v", pid, filtered.Visible(pid), expected)
+					}
+				}
+				// Also check that no unexpected PIDs are included
+				for _, pid := range filtered.PIDs() {
+					if !tt.expectedPidsShow[pid] {
+						t.Errorf("PID %d included but not expected", pid)
+					}
+				}
+			}
+		})
+	}
+}
+
+// Helper function to compare int slices
+func equalIntSlices(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTreeRelationships(t *testing.T) {
+	all := New(testProcesses())
+	filtered := all.Filter(Filter{Users: []string{"user1"}})
+
+	tests := []struct {
+		name     string
+		got      []int
+		expected []int
+	}{
+		{"children", all.Children(1), []int{2, 3}},
+		{"children of leaf", all.Children(6), nil},
+		{"children filtered", filtered.Children(1), []int{3}},
+		{"ancestors", all.Ancestors(6), []int{5, 3, 1}},
+		{"ancestors of root", all.Ancestors(1), nil},
+		{"ancestors of unknown PID", all.Ancestors(99), nil},
+		{"descendants", all.Descendants(3), []int{4, 5, 6}},
+		{"descendants filtered", filtered.Descendants(3), []int{5, 6}},
+		{"descendants of unknown PID", all.Descendants(99), nil},
+		{"roots", all.Roots(), []int{1}},
+		{"pids filtered", filtered.PIDs(), []int{1, 3, 5, 6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !slices.Equal(tt.got, tt.expected) {
+				t.Errorf("got %v, want %v", tt.got, tt.expected)
+			}
+		})
+	}
+
+	if !filtered.Matched(5) || filtered.Matched(3) || all.Matched(5) {
+		t.Errorf("Matched() wrong: filtered 5=%v, filtered 3=%v, unfiltered 5=%v",
+			filtered.Matched(5), filtered.Matched(3), all.Matched(5))
+	}
+
+	// Hidden processes can still be looked up
+	if p := filtered.Process(2); p == nil || p.Command != "kernel_task" {
+		t.Errorf("Process(2) = %v, want kernel_task", p)
+	}
+	if filtered.Visible(2) {
+		t.Errorf("Visible(2) = true, want false")
+	}
+}
+
+func TestFilterFunc(t *testing.T) {
+	filtered := New(testProcesses()).Filter(Filter{
+		Func: func(p *Process) bool { return p.Command == "cron" },
+	})
+
+	if got := filtered.PIDs(); !slices.Equal(got, []int{1, 3, 4}) {
+		t.Errorf("PIDs() = %v, want [1 3 4]", got)
+	}
+
+	// Filtering a filtered tree narrows it further
+	narrowed := filtered.Filter(Filter{Users: []string{"root", "user1"}})
+	if got := narrowed.PIDs(); !slices.Equal(got, []int{1, 3, 4}) {
+		t.Errorf("PIDs() = %v, want [1 3 4]", got)
+	}
+	if !narrowed.Matched(1) || narrowed.Matched(5) {
+		t.Errorf("hidden processes should not match a further filter")
+	}
+}
+
+func TestFilterPorts(t *testing.T) {
+	processes := testProcesses()
+	processes[3].Sockets = []Socket{{Proto: "unix", Local: "/run/cron.sock", Listen: true}}
+	processes[5].Sockets = []Socket{{Proto: "tcp6", Local: "[::]:8080", Port: 8080, Listen: true}}
+
+	filtered := New(processes).Filter(Filter{Ports: []int{8080}})
+	if got := filtered.PIDs(); !slices.Equal(got, []int{1, 3, 5, 6}) {
+		t.Errorf("PIDs() = %v, want [1 3 5 6]", got)
+	}
+	if !filtered.Matched(6) || filtered.Matched(5) {
+		t.Errorf("only the socket's owner should match")
+	}
+
+	// Unix sockets have no port
+	if got := New(processes).Filter(Filter{Ports: []int{0}}).PIDs(); len(got) != 0 {
+		t.Errorf("PIDs() for port 0 = %v, want none", got)
+	}
+}
+
+func TestFilterTTYs(t *testing.T) {
+	processes := testProcesses()
+	processes[4].Session = &Session{SID: 5, PGID: 5, TTY: "pts/3"}
+	processes[5].Session = &Session{SID: 5, PGID: 6, TTY: "pts/3"}
+	processes[3].Session = &Session{SID: 4, PGID: 4}
+
+	filtered := New(processes).Filter(Filter{TTYs: []string{"pts/3"}})
+	if got := filtered.PIDs(); !slices.Equal(got, []int{1, 3, 5, 6}) {
+		t.Errorf("PIDs() = %v, want [1 3 5 6]", got)
+	}
+	if !filtered.Matched(5) || !filtered.Matched(6) || filtered.Matched(4) {
+		t.Errorf("only processes on pts/3 should match")
+	}
+}
+
+func TestWalk(t *testing.T) {
+	all := New(testProcesses())
+
+	type visit struct {
+		pid         int
+		depth       int
+		last        bool
+		hasChildren bool
+	}
+	var visits []visit
+	err := all.Walk(func(n Node) error {
+		visits = append(visits, visit{n.Process.PID, n.Depth, n.Last, n.HasChildren})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk() error: %v", err)
+	}
+
+	expected := []visit{
+		{1, 0, true, true},
+		{2, 1, false, false},
+		{3, 1, true, true},
+		{4, 2, false, false},
+		{5, 2, true, true},
+		{6, 3, true, false},
+	}
+	if len(visits) != len(expected) {
+		t.Fatalf("visited %v, want %v", visits, expected)
+	}
+	for i := range expected {
+		if visits[i] != expected[i] {
+			t.Errorf("visit %d = %+v, want %+v", i, visits[i], expected[i])
+		}
+	}
+
+	// SkipChildren prunes a subtree
+	var pids []int
+	_ = all.Walk(func(n Node) error {
+		pids = append(pids, n.Process.PID)
+		if n.Process.PID == 3 {
+			return SkipChildren
+		}
+		return nil
+	})
+	if !slices.Equal(pids, []int{1, 2, 3}) {
+		t.Errorf("Walk with SkipChildren visited %v, want [1 2 3]", pids)
+	}
+
+	// Other errors stop the walk
+	stop := errors.New("stop")
+	pids = nil
+	err = all.Walk(func(n Node) error {
+		pids = append(pids, n.Process.PID)
+		if n.Process.PID == 2 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || !slices.Equal(pids, []int{1, 2}) {
+		t.Errorf("Walk returned %v after %v, want stop after [1 2]", err, pids)
+	}
+}
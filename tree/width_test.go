@@ -0,0 +1,104 @@
+package tree
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"", 0},
+		{"vim", 3},
+		{"├─┬─", 4},
+		{"日本語", 6},
+		{"ｆｕｌｌ", 8},
+		{"한국어", 6},
+		{"🐍 python", 9},
+		{"cafe\u0301", 4},          // Combining acute accent
+		{"a\u200bb", 2},            // Zero-width space
+		{"👩\u200d💻", 4},            // ZWJ sequence, drawn as two emoji by most terminals
+		{"\033[1;33m漢字\033[0m", 4}, // Escapes take no columns
+	}
+
+	for _, tt := range tests {
+		if got := DisplayWidth(tt.input); got != tt.expected {
+			t.Errorf("DisplayWidth(%q) = %d, want %d", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestTruncateWidth(t *testing.T) {
+	tests := []struct {
+		input    string
+		width    int
+		expected string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"a long command line", 10, "a long ..."},
+		{"日本語のコマンド", 10, "日本語..."},
+		{"日本語のコマンド", 9, "日本語..."},
+		{"a日本語のコマンド", 9, "a日本..."}, // A wide character is never split
+		{"cafe\u0301 au lait", 7, "cafe\u0301..."},
+		{"\033[33m漢字漢字漢字\033[0m", 7, "\033[33m漢字...\033[0m"},
+		{"anything", 2, ".."},
+	}
+
+	for _, tt := range tests {
+		got := TruncateWidth(tt.input, tt.width)
+		if got != tt.expected {
+			t.Errorf("TruncateWidth(%q, %d) = %q, want %q", tt.input, tt.width, got, tt.expected)
+		}
+		if w := DisplayWidth(got); w > tt.width {
+			t.Errorf("TruncateWidth(%q, %d) is %d columns wide", tt.input, tt.width, w)
+		}
+	}
+
+	if got := PadRight("日本", 6) + "|"; got != "日本  |" {
+		t.Errorf("PadRight = %q", got)
+	}
+	if got := PadLeft("日本", 6); got != "  日本" {
+		t.Errorf("PadLeft = %q", got)
+	}
+}
+
+func TestRenderWideText(t *testing.T) {
+	processes := []Process{
+		{PID: 1, PPID: 0, User: "root", Command: "/sbin/init"},
+		{PID: 10, PPID: 1, User: "山田太郎さんの", Command: "python3 日本語のスクリプト.py --verbose"},
+		{PID: 11, PPID: 10, User: "user", Command: "🐍 worker --queue=默认"},
+	}
+
+	var out strings.Builder
+	r := &Renderer{Indent: 2, Width: 90}
+	if err := r.Render(&out, New(processes)); err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+
+	// Every line fits the terminal, and the columns after USER line up
+	commandColumn := -1
+	for i, line := range lines[2:] {
+		if w := DisplayWidth(line); w > r.Width {
+			t.Errorf("Line %d is %d columns wide, over %d:\n%s", i, w, r.Width, line)
+		}
+		column := DisplayWidth(line[:strings.Index(line, "0.0")])
+		if commandColumn >= 0 && column != commandColumn {
+			t.Errorf("Line %d %%CPU at column %d, expected %d:\n%s", i, column, commandColumn, line)
+		}
+		commandColumn = column
+	}
+
+	if !strings.Contains(lines[3], "山田太... ") {
+		t.Errorf("wide username not truncated to 10 columns:\n%s", lines[3])
+	}
+	if !strings.HasSuffix(lines[3], "...") {
+		t.Errorf("wide command not truncated:\n%s", lines[3])
+	}
+	if !strings.HasSuffix(lines[4], "🐍 worker --queue=默认") {
+		t.Errorf("command that fits was truncated:\n%s", lines[4])
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+// WaitCmd holds the args of the wait subcommand
+type WaitCmd struct {
+	Timeout  time.Duration `name:"timeout" help:"Give up after this long and exit non-zero; 0 waits forever (default: 0)" default:"0"`
+	Interval time.Duration `name:"interval" help:"How often to check the processes (default: 1s)" default:"1s"`
+}
+
+// errTimedOut is returned by wait when processes remain after --timeout
+var errTimedOut = errors.New("timed out")
+
+// runWait waits until the processes matching the CLI filters and all their
+// descendants have exited, showing those remaining on out whenever they change.
+// If redraw is set, each display replaces the previous one.
+func runWait(cli CLI, platform tree.Platform, out io.Writer, redraw bool) error {
+	pt, err := snapshot(cli, platform)
+	if err != nil {
+		return err
+	}
+	if !pt.tree.Filtered() {
+		return usagef("choose processes to wait for, e.g. -p PID")
+	}
+
+	var deadline time.Time
+	if cli.Wait.Timeout > 0 {
+		deadline = time.Now().Add(cli.Wait.Timeout)
+	}
+
+	// Members are every process seen in the family, so children that outlive
+	// their parent and are reparented are still waited for
+	members := make(map[int]tree.Process)
+	remaining := pt.subtreeProcesses()
+	shown := ""
+	shownLines := 0
+
+	for {
+		for _, p := range remaining {
+			members[p.PID] = p
+		}
+
+		var display bytes.Buffer
+		if len(members) == 0 {
+			fmt.Fprintln(&display, "No matching processes")
+		} else if len(remaining) == 0 {
+			fmt.Fprintln(&display, "All processes exited")
+		} else {
+			fmt.Fprintf(&display, "Waiting for %s\n", processCount(len(remaining)))
+			if err := pt.renderer.Render(&display, tree.New(remaining)); err != nil {
+				return err
+			}
+		}
+		if display.String() != shown {
+			if redraw && shownLines > 0 {
+				// Move up over the previous display and clear to the end of the screen
+				fmt.Fprintf(out, "\033[%dA\033[J", shownLines)
+			}
+			shown = display.String()
+			shownLines = bytes.Count(display.Bytes(), []byte("\n"))
+			if _, err := out.Write(display.Bytes()); err != nil {
+				return err
+			}
+		}
+
+		if len(remaining) == 0 {
+			return nil
+		}
+
+		sleep := cli.Wait.Interval
+		if !deadline.IsZero() {
+			left := time.Until(deadline)
+			if left <= 0 {
+				return fmt.Errorf("%w with %s remaining", errTimedOut, processCount(len(remaining)))
+			}
+			sleep = min(sleep, left)
+		}
+		time.Sleep(sleep)
+
+		processList, err := platform.GetProcesses()
+		if err != nil {
+			return fmt.Errorf("failed to get processes: %v", err)
+		}
+		pt.tree = tree.New(withoutSelf(processList)).Filter(tree.Filter{
+			Func: func(p *tree.Process) bool {
+				member, ok := members[p.PID]
+				return ok && sameProcess(member, *p)
+			},
+		})
+		remaining = pt.subtreeProcesses()
+	}
+}
+
+// processCount returns n with "process" or "processes"
+func processCount(n int) string {
+	if n == 1 {
+		return "1 process"
+	}
+	return fmt.Sprintf("%d processes", n)
+}
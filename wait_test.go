@@ -0,0 +1,109 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeremywohl/proktree/tree"
+)
+
+func TestRunWait(t *testing.T) {
+	all := killTestProcesses()
+	initProc, makeProc, cc, ccB, vim := all[0], all[1], all[2], all[4], all[5]
+
+	// make exits, and its remaining children are reparented to init
+	orphanedCC, orphanedCCB := cc, ccB
+	orphanedCC.PPID, orphanedCCB.PPID = 1, 1
+
+	// A new child of an orphan is still part of the family
+	started := time.Unix(1750000100, 0)
+	as := tree.Process{PID: 14, PPID: 11, User: "alice", StartTime: &started, Command: "as a.s"}
+
+	// A process reusing make's PID is not
+	reused := tree.Process{PID: 10, PPID: 1, User: "carol", StartTime: &started, Command: "make"}
+
+	tests := []struct {
+		name    string
+		cli     CLI
+		lists   [][]tree.Process
+		redraw  bool
+		output  []string
+		wantErr string
+	}{
+		{
+			name: "family shrinks and exits",
+			cli:  CLI{PIDs: []string{"10"}},
+			lists: [][]tree.Process{
+				all,
+				{initProc, makeProc, cc, ccB, vim},
+				{initProc, orphanedCC, orphanedCCB, reused, vim},
+				{initProc, orphanedCC, orphanedCCB, as, reused, vim},
+				{initProc, orphanedCCB, reused, vim},
+				{initProc, reused, vim},
+			},
+			output: []string{
+				"Waiting for 4 processes\n", "cc1 a.c",
+				"Waiting for 3 processes\n",
+				"Waiting for 2 processes\n",
+				"as a.s",
+				"Waiting for 1 process\n",
+				"All processes exited\n",
+			},
+		},
+		{
+			name:   "redraw replaces the previous display",
+			cli:    CLI{PIDs: []string{"11"}},
+			lists:  [][]tree.Process{all, {initProc, vim}},
+			redraw: true,
+			output: []string{"Waiting for 2 processes\n", "\033[5A\033[JAll processes exited\n"},
+		},
+		{
+			name:    "timeout",
+			cli:     CLI{SearchStrings: []string{"cc"}, Wait: WaitCmd{Timeout: 20 * time.Millisecond}},
+			lists:   [][]tree.Process{all},
+			output:  []string{"Waiting for 3 processes\n"},
+			wantErr: "timed out with 3 processes remaining",
+		},
+		{
+			name:   "nothing to wait for",
+			cli:    CLI{Users: []string{"nobody"}},
+			lists:  [][]tree.Process{all},
+			output: []string{"No matching processes\n"},
+		},
+		{
+			name:    "no filters",
+			lists:   [][]tree.Process{all},
+			wantErr: "choose processes to wait for, e.g. -p PID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cli.Wait.Interval = time.Millisecond
+
+			var out strings.Builder
+			err := runWait(tt.cli, &sequencePlatform{lists: tt.lists}, &out, tt.redraw)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("runWait() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("runWait() error: %v", err)
+			}
+
+			// Expected output appears in order
+			rest := out.String()
+			for _, expected := range tt.output {
+				i := strings.Index(rest, expected)
+				if i < 0 {
+					t.Fatalf("output missing %q in order:\n%s", expected, out.String())
+				}
+				rest = rest[i+len(expected):]
+			}
+			if !tt.redraw && strings.Contains(out.String(), "\033[") {
+				t.Errorf("output has escape sequences without redraw:\n%q", out.String())
+			}
+		})
+	}
+}